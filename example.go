@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"parsego/parser"
 	"parsego/parsetree"
+	"strings"
 	"time"
 )
 
@@ -45,7 +47,7 @@ const (
 	PROGRAM
 )
 
-var NODE_TYPES = map[int]string{
+var NODE_TYPES = pt.NodeTypes{
 	TYPE_UNDEFINED: "?",
 
 	IDENTIFIER:          "IDENTIFIER",
@@ -215,13 +217,14 @@ func AndExpression() pg.Parser {
 		| Sum
 */
 func Comparison() pg.Parser {
-	return pg.TryAny(
-		LComparison(),
-		LEComparison(),
-		GComparison(),
-		GEComparison(),
-		EComparison(),
-		Sum())
+	return pg.Label("Comparison",
+		pg.TryAny(
+			LComparison(),
+			LEComparison(),
+			GComparison(),
+			GEComparison(),
+			EComparison(),
+			Sum()))
 }
 
 /*
@@ -296,36 +299,38 @@ func EComparison() pg.Parser {
 	Sum ← Product ('+' | '-') Product | Product
 */
 func Sum() pg.Parser {
-	return pg.TryAny(
-		pg.Specify(SUM,
-			pg.Concat(
-				Product(),
-				pg.Trim(
-					pg.Concat(
-						pg.Skip(
-							SumOperator()),
-						pg.Whitespaces(),
-						Product())))),
-		pg.Trim(
-			Product()))
+	return pg.Label("Sum",
+		pg.TryAny(
+			pg.Specify(SUM,
+				pg.Concat(
+					Product(),
+					pg.Trim(
+						pg.Concat(
+							pg.Skip(
+								SumOperator()),
+							pg.Whitespaces(),
+							Product())))),
+			pg.Trim(
+				Product())))
 }
 
 /*
 	Product ← Value ('*' | '/') Value | Value
 */
 func Product() pg.Parser {
-	return pg.TryAny(
-		pg.Specify(PRODUCT,
-			pg.Concat(
-				Value(),
-				pg.Trim(
-					pg.Concat(
-						pg.Skip(
-							ProductOperator()),
-						pg.Whitespaces(),
-						Value())))),
-		pg.Trim(
-			Value()))
+	return pg.Label("Product",
+		pg.TryAny(
+			pg.Specify(PRODUCT,
+				pg.Concat(
+					Value(),
+					pg.Trim(
+						pg.Concat(
+							pg.Skip(
+								ProductOperator()),
+							pg.Whitespaces(),
+							Value())))),
+			pg.Trim(
+				Value())))
 }
 
 /*
@@ -751,6 +756,189 @@ func Program() pg.Parser {
 			Statement()))
 }
 
+/*
+	Desugars  'for x in y Block'  into
+	'for _i = 0; _i < len(y); _i = _i + 1 { x = at(y, _i) Block }'
+*/
+func DesugarForeach(tree *pt.ParseTree) *pt.ParseTree {
+	return pt.Transform(tree, func(node *pt.ParseTree) (*pt.ParseTree, bool) {
+		if node.Type != FOREACH || len(node.Children) != 3 || node.Children[2].Type != BLOCK {
+			return node, true
+		}
+		item, items, block := node.Children[0], node.Children[1], node.Children[2]
+		index := fmt.Sprintf("_i%d", node.Position.StartPosition)
+		leaf := func(nodeType int, value string) *pt.ParseTree {
+			return &pt.ParseTree{Type: nodeType, Value: []byte(value), Position: node.Position}
+		}
+		branch := func(nodeType int, children ...*pt.ParseTree) *pt.ParseTree {
+			return &pt.ParseTree{Type: nodeType, Children: children, Position: node.Position}
+		}
+		call := func(name string, params ...*pt.ParseTree) *pt.ParseTree {
+			children := []*pt.ParseTree{leaf(IDENTIFIER, name)}
+			for _, param := range params {
+				children = append(children, pt.Wrap(EXPRESSION, param))
+			}
+			return branch(FUNCTION_CALL, children...)
+		}
+
+		pt.InsertChildren(block, 0,
+			branch(ASSIGNMENT,
+				item,
+				pt.Wrap(EXPRESSION,
+					call("at", items, leaf(IDENTIFIER, index)))))
+		return branch(FOR,
+			branch(FOR_INIT,
+				branch(ASSIGNMENT,
+					leaf(IDENTIFIER, index),
+					pt.Wrap(EXPRESSION,
+						leaf(NUMBER_LITERAL, "0")))),
+			branch(FOR_CONDITION,
+				pt.Wrap(EXPRESSION,
+					branch(L_COMPARISON,
+						leaf(IDENTIFIER, index),
+						call("len", pt.Clone(items))))),
+			branch(FOR_STEP,
+				branch(ASSIGNMENT,
+					leaf(IDENTIFIER, index),
+					pt.Wrap(EXPRESSION,
+						branch(SUM,
+							leaf(IDENTIFIER, index),
+							leaf(NUMBER_LITERAL, "1"))))),
+			block), true
+	})
+}
+
+var OPERATORS = map[int]string{
+	OR_EXPRESSION:  "||",
+	AND_EXPRESSION: "&&",
+	L_COMPARISON:   "<",
+	L_E_COMPARISON: "<=",
+	G_COMPARISON:   ">",
+	G_E_COMPARISON: ">=",
+	E_COMPARISON:   "==",
+	SUM:            "+",
+	PRODUCT:        "*",
+}
+
+/*
+	Prints a program back to source text, one statement per line,
+	parsing to the same trees but for positions.
+	Sums and products print as + and *, as trees keep no operator.
+*/
+func FormatProgram(trees []*pt.ParseTree) string {
+	var out strings.Builder
+	for _, tree := range trees {
+		formatStatements(&out, tree.Children, 0)
+	}
+	return out.String()
+}
+
+func formatStatements(out *strings.Builder, statements []*pt.ParseTree, level int) {
+	for _, statement := range statements {
+		out.WriteString(strings.Repeat("\t", level))
+		formatStatement(out, statement, level)
+		out.WriteString("\n")
+	}
+}
+
+func formatStatement(out *strings.Builder, node *pt.ParseTree, level int) {
+	children := node.Children
+	switch node.Type {
+	case ASSIGNMENT:
+		fmt.Fprintf(out, "%s = %s", children[0].Value, formatExpression(children[1]))
+	case FUNCTION_CALL:
+		out.WriteString(formatValue(node))
+	case FUNCTION_DEFINITION:
+		params := []string{}
+		for _, param := range children[1 : len(children)-1] {
+			params = append(params, string(param.Value))
+		}
+		fmt.Fprintf(out, "func %s(%s) ", children[0].Value, strings.Join(params, ", "))
+		formatBlock(out, children[len(children)-1], level)
+	case BREAK:
+		out.WriteString("break")
+	case CONTINUE:
+		out.WriteString("continue")
+	case RETURN:
+		fmt.Fprintf(out, "return %s", formatExpression(children[0]))
+	case FOREACH:
+		fmt.Fprintf(out, "for %s in %s ", children[0].Value, children[1].Value)
+		formatBlock(out, children[2], level)
+	case FOR:
+		fmt.Fprintf(out, "for %s; %s; %s ",
+			formatAssignments(children[0], level),
+			formatExpression(children[1].Children[0]),
+			formatAssignments(children[2], level))
+		formatBlock(out, children[3], level)
+	case IFTHEN, IFTHENELSE:
+		fmt.Fprintf(out, "if %s ", formatExpression(children[0]))
+		formatBlock(out, children[1], level)
+		if node.Type == IFTHENELSE {
+			out.WriteString(" else ")
+			formatBlock(out, children[2], level)
+		}
+	case SWITCH:
+		fmt.Fprintf(out, "switch %s {\n", formatExpression(children[0]))
+		for _, branch := range children[1:] {
+			out.WriteString(strings.Repeat("\t", level+1))
+			if branch.Type == CASE {
+				fmt.Fprintf(out, "case %s: ", formatExpression(branch.Children[0]))
+			} else {
+				out.WriteString("else: ")
+			}
+			formatBlock(out, branch.Children[len(branch.Children)-1], level+1)
+			out.WriteString("\n")
+		}
+		out.WriteString(strings.Repeat("\t", level) + "}")
+	}
+}
+
+func formatBlock(out *strings.Builder, block *pt.ParseTree, level int) {
+	out.WriteString("{\n")
+	formatStatements(out, block.Children, level+1)
+	out.WriteString(strings.Repeat("\t", level) + "}")
+}
+
+func formatAssignments(list *pt.ParseTree, level int) string {
+	var out strings.Builder
+	for i, assignment := range list.Children {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		formatStatement(&out, assignment, level)
+	}
+	return out.String()
+}
+
+/*
+	An EXPRESSION node, whose child is the expression itself
+*/
+func formatExpression(node *pt.ParseTree) string {
+	return formatValue(node.Children[0])
+}
+
+/*
+	An operand, an EXPRESSION being one in parentheses
+*/
+func formatValue(node *pt.ParseTree) string {
+	if operator, ok := OPERATORS[node.Type]; ok {
+		return formatValue(node.Children[0]) + " " + operator + " " + formatValue(node.Children[1])
+	}
+	switch node.Type {
+	case EXPRESSION:
+		return "(" + formatExpression(node) + ")"
+	case STRING_LITERAL:
+		return `"` + string(node.Value) + `"`
+	case FUNCTION_CALL:
+		params := []string{}
+		for _, param := range node.Children[1:] {
+			params = append(params, formatExpression(param))
+		}
+		return string(node.Children[0].Value) + "(" + strings.Join(params, ", ") + ")"
+	}
+	return string(node.Value)
+}
+
 /*
 
 */
@@ -794,7 +982,7 @@ func main() {
 	end := time.Now()
 
 	for _, o := range out {
-		o.Walk(0, printNode, none, nil)
+		pt.WriteText(os.Stdout, o, NODE_TYPES)
 	}
 	fmt.Printf("Input length: %d, probe count: %d, total: %s\n", len(in.GetInput()), in.GetProbeCount(), end.Sub(start).String())
 	fmt.Printf("Parse ok: %t\n", ok)
@@ -802,13 +990,3 @@ func main() {
 		fmt.Printf("Early stop at line: %d\n", in.GetLineCount())
 	}
 }
-
-func printNode(level int, node *pt.ParseTree, e interface{}) bool {
-	for i := 0; i < level; i += 1 {
-		fmt.Print("|  ")
-	}
-	fmt.Printf("%s [%s]\n", NODE_TYPES[node.Type], node.Value)
-	return false
-}
-
-func none(level int, node *pt.ParseTree, e interface{}) bool { return false }
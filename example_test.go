@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"parsego/examples/program"
+	"parsego/parser"
+	"parsego/parsetree"
+	"parsego/pgtest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestProgram(t *testing.T) {
+	pgtest.Run(t, "testdata/program", Program(), NODE_TYPES)
+}
+
+func TestProgramGrammar(t *testing.T) {
+	for _, issue := range pg.Check(pg.Describe(Program()), NODE_TYPES) {
+		t.Error(issue)
+	}
+}
+
+func FuzzProgram(f *testing.F) {
+	pgtest.Fuzz(f, Program(), NODE_TYPES, 6, FormatProgram)
+}
+
+func TestFormatProgram(t *testing.T) {
+	program := Program()
+	for _, input := range programInputs(t) {
+		if out, err := pg.Parse(program, input); err == nil {
+			pgtest.Reprint(t, program, NODE_TYPES, FormatProgram, out, input)
+		}
+	}
+}
+
+func TestGeneratedProgram(t *testing.T) {
+	pgtest.Generated(t, "src/parsego/examples/program/parser.go", "program", Program(), NODE_TYPES)
+	pgtest.Equivalent(t, Program(), NODE_TYPES, func(input string) ([]*pt.ParseTree, error) {
+		out, err := program.Parse(input)
+		return pgtest.Convert(out), err
+	}, programInputs(t)...)
+}
+
+func TestBytecodeProgram(t *testing.T) {
+	bytecode := pg.CompileBytecode(pg.Describe(Program()))
+	pgtest.Equivalent(t, Program(), NODE_TYPES, bytecode.Parse, programInputs(t)...)
+	bytecode.MaxDepth = 8
+	if _, err := bytecode.Parse("a = (((((1)))))"); !errors.Is(err, pg.ErrStackDepth) {
+		t.Fatalf("got %v, want %s", err, pg.ErrStackDepth)
+	}
+}
+
+func TestReaderProgram(t *testing.T) {
+	program := Program()
+	pgtest.Equivalent(t, program, NODE_TYPES, func(input string) ([]*pt.ParseTree, error) {
+		return pg.ParseReader(program, iotest.HalfReader(strings.NewReader(input)))
+	}, programInputs(t)...)
+}
+
+func TestDesugarForeach(t *testing.T) {
+	out, err := pg.Parse(Program(), "for x in xs { print(x) }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text strings.Builder
+	pt.WriteSExpr(&text, DesugarForeach(out[0]), NODE_TYPES)
+	expected := `(PROGRAM
+  (FOR
+    (FOR_INIT
+      (ASSIGNMENT
+        (IDENTIFIER "_i0")
+        (EXPRESSION
+          (NUMBER_LITERAL "0"))))
+    (FOR_CONDITION
+      (EXPRESSION
+        (L_COMPARISON
+          (IDENTIFIER "_i0")
+          (FUNCTION_CALL
+            (IDENTIFIER "len")
+            (EXPRESSION
+              (IDENTIFIER "xs"))))))
+    (FOR_STEP
+      (ASSIGNMENT
+        (IDENTIFIER "_i0")
+        (EXPRESSION
+          (SUM
+            (IDENTIFIER "_i0")
+            (NUMBER_LITERAL "1")))))
+    (BLOCK
+      (ASSIGNMENT
+        (IDENTIFIER "x")
+        (EXPRESSION
+          (FUNCTION_CALL
+            (IDENTIFIER "at")
+            (EXPRESSION
+              (IDENTIFIER "xs"))
+            (EXPRESSION
+              (IDENTIFIER "_i0")))))
+      (FUNCTION_CALL
+        (IDENTIFIER "print")
+        (EXPRESSION
+          (IDENTIFIER "x"))))))
+`
+	if text.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, text.String())
+	}
+
+	malformed := &pt.ParseTree{Type: FOREACH, Children: []*pt.ParseTree{{Type: IDENTIFIER}}}
+	if DesugarForeach(malformed) != malformed {
+		t.Error("expected a FOREACH of another shape to be left unchanged")
+	}
+}
+
+/*
+	Trees from an arena, reset between parses, are the trees from the heap
+*/
+func TestArenaProgram(t *testing.T) {
+	testArena(t, Program(), programInputs(t)...)
+}
+
+/*
+	Failed Try and Optional rewind the arena over the trees they built,
+	while the trees before them are still in use and the next ones
+	take the place of the dropped ones
+*/
+func TestArenaRewind(t *testing.T) {
+	item := pg.Any(
+		pg.Try(pg.Concat(NumberLiteral(), pg.Character('!'))),
+		Identifier(),
+		NumberLiteral())
+	list := pg.Concat(
+		item,
+		pg.Optional(pg.Concat(pg.Character(','), item, pg.Character(';'))),
+		pg.Many(pg.Concat(pg.Character(' '), item)))
+	testArena(t, list, "1", "12!", "a,1;", "a,1 2!", "12,b3 4! c", "1,2!; 3 x4 5!", "1,2, 3", "1 2!!")
+}
+
+func testArena(t *testing.T, rule pg.Parser, inputs ...string) {
+	bytecode := pg.CompileBytecode(pg.Describe(rule))
+	arena := pg.NewArena()
+	defer arena.Release()
+	for _, match := range []pg.Parser{rule, bytecode.Parser()} {
+		pgtest.Equivalent(t, rule, NODE_TYPES, func(input string) ([]*pt.ParseTree, error) {
+			arena.Reset()
+			state := pg.InitParser()
+			state.SetInput(input)
+			state.SetArena(arena)
+			return pg.ParseWith(match, state)
+		}, inputs...)
+	}
+}
+
+/*
+	The test inputs, generated sentences and their first halves
+*/
+func programInputs(t *testing.T) []string {
+	inputs := pgtest.Inputs(t, "testdata/program")
+	for _, sentence := range pgtest.Sentences(Program(), 200, 6) {
+		inputs = append(inputs, sentence, sentence[:len(sentence)/2])
+	}
+	return inputs
+}
+
+/*
+	The example grammar over generated inputs of increasing size:
+	assignments of literals, and sentences generated from the grammar
+*/
+func BenchmarkProgram(b *testing.B) {
+	program := warmProgram(b)
+	b.Run("assignments", func(b *testing.B) {
+		pgtest.Scaling(b, program, programAssignments, 1<<10, 16<<10, 256<<10)
+	})
+	b.Run("sentences", func(b *testing.B) {
+		pgtest.Scaling(b, program, programSentences, 1<<10, 16<<10, 256<<10, 1<<20)
+	})
+}
+
+/*
+	Parses megabytes with trees from the heap and from an arena
+*/
+func BenchmarkProgramArena(b *testing.B) {
+	program := warmProgram(b)
+	for _, size := range []int{64 << 10, 1 << 20} {
+		input := programAssignments(size)
+		b.Run(pgtest.SizeName(size)+"/heap", func(b *testing.B) {
+			pgtest.Benchmark(b, program, input)
+		})
+		b.Run(pgtest.SizeName(size)+"/arena", func(b *testing.B) {
+			pgtest.BenchmarkArena(b, program, input)
+		})
+	}
+}
+
+/*
+	The closures and the bytecode machine on the same inputs
+*/
+func BenchmarkProgramBytecode(b *testing.B) {
+	program := warmProgram(b)
+	backends := []struct {
+		name string
+		rule pg.Parser
+	}{
+		{"closures", program},
+		{"bytecode", pg.CompileBytecode(pg.Describe(program)).Parser()},
+	}
+	inputs := []struct {
+		name  string
+		input string
+	}{
+		{"assignments", programAssignments(16 << 10)},
+		{"sentences", programSentences(16 << 10)},
+	}
+	for _, input := range inputs {
+		for _, backend := range backends {
+			b.Run(input.name+"/"+backend.name, func(b *testing.B) {
+				pgtest.Benchmark(b, backend.rule, input.input)
+			})
+		}
+	}
+}
+
+/*
+	The program rule, having parsed the test inputs once
+*/
+func warmProgram(b *testing.B) pg.Parser {
+	program := Program()
+	for _, input := range pgtest.Inputs(b, "testdata/program") {
+		pg.Parse(program, input)
+	}
+	return program
+}
+
+/*
+	Assignments of literals of every kind, about size bytes of them
+*/
+func programAssignments(size int) string {
+	values := []string{"1", `"text"`, "true", "name"}
+	var text strings.Builder
+	for i := 0; text.Len() < size; i += 1 {
+		fmt.Fprintf(&text, "v%d = %s\n", i, values[i%len(values)])
+	}
+	return text.String()
+}
+
+/*
+	Sentences generated from the grammar, about size bytes of them
+*/
+func programSentences(size int) string {
+	program := Program()
+	var text strings.Builder
+	for seed := int64(0); text.Len() < size; seed += 1 {
+		if sentence, err := pg.Generate(program, rand.New(rand.NewSource(seed)), 6); err == nil {
+			text.WriteString(sentence)
+			text.WriteString("\n")
+		}
+	}
+	return text.String()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"parsego/parser"
+)
+
+/*
+	parsego check --grammar calc.peg [--start Sum]
+
+	Reports the mistakes pg.Check finds in the rules reachable from the
+	start rule, or in every rule without --start, one per line.
+*/
+func check(std *streams, args []string) error {
+	flags := newFlagSet(std, "parsego check")
+	grammarFlags := addGrammarFlags(flags)
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := noArguments(flags, args); err != nil {
+		return err
+	}
+
+	grammar, rule, err := grammarFlags.load()
+	if err != nil {
+		return err
+	}
+	rules := []pg.Parser{rule}
+	if *grammarFlags.start == "" {
+		rules = rules[:0]
+		for _, name := range grammar.Names {
+			rules = append(rules, grammar.Rule(name))
+		}
+	}
+	// rules reachable from several others are reported once
+	reported := make(map[string]bool)
+	for _, rule := range rules {
+		for _, issue := range pg.Check(pg.Describe(rule), grammar.Types) {
+			if !reported[issue.Error()] {
+				reported[issue.Error()] = true
+				fmt.Fprintf(std.stdout, "%s: %s\n", *grammarFlags.file, issue)
+			}
+		}
+	}
+	if len(reported) > 0 {
+		return errReported
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"parsego/parser"
+)
+
+/*
+	parsego gen --grammar calc.peg [--start Sum] [--package calc] [--out parser.go]
+
+	Grammars written in Go are generated by calling pg.WriteGo instead.
+*/
+func gen(std *streams, args []string) error {
+	flags := newFlagSet(std, "parsego gen")
+	grammarFlags := addGrammarFlags(flags)
+	pkg := flags.String("package", "parser", "package of the generated parser")
+	out := flags.String("out", "", "output file, the standard output by default")
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := noArguments(flags, args); err != nil {
+		return err
+	}
+
+	grammar, rule, err := grammarFlags.load()
+	if err != nil {
+		return err
+	}
+	var source bytes.Buffer
+	if err := pg.WriteGo(&source, pg.Describe(rule), grammar.Types, *pkg); err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = std.stdout.Write(source.Bytes())
+		return err
+	}
+	return os.WriteFile(*out, source.Bytes(), 0644)
+}
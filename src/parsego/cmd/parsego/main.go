@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"parsego/parser"
+	"path/filepath"
+	"strings"
+)
+
+/*
+	The parsego command, run as
+	parsego COMMAND [flags] [arguments]
+*/
+
+type command struct {
+	name    string
+	summary string
+	run     func(std *streams, args []string) error
+}
+
+var commands = []*command{
+	{"parse", "parses input with a grammar and writes the trees", parse},
+	{"check", "reports grammar mistakes", check},
+	{"trace", "parses input with a grammar, tracing the rules tried", trace},
+	{"repl", "parses lines typed in, with rules redefined on the fly", repl},
+	{"gen", "writes a standalone Go parser for a grammar", gen},
+}
+
+/*
+	The standard streams of a command
+*/
+type streams struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+/*
+	Returned by commands that already reported why they failed
+*/
+var errReported = errors.New("failed")
+
+/*
+	Returned by commands given wrong flags, once their usage is written
+*/
+var errUsage = errors.New("usage")
+
+func main() {
+	os.Exit(run(&streams{os.Stdin, os.Stdout, os.Stderr}, os.Args[1:]))
+}
+
+/*
+	Runs the command named by args[0], returning the exit status
+*/
+func run(std *streams, args []string) int {
+	if len(args) > 0 {
+		for _, command := range commands {
+			if command.name != args[0] {
+				continue
+			}
+			switch err := command.run(std, args[1:]); err {
+			case nil, flag.ErrHelp:
+				return 0
+			case errUsage:
+				return 2
+			case errReported:
+				return 1
+			default:
+				fmt.Fprintf(std.stderr, "parsego %s: %s\n", command.name, err)
+				return 1
+			}
+		}
+	}
+	fmt.Fprintln(std.stderr, "usage: parsego COMMAND [flags]")
+	for _, command := range commands {
+		fmt.Fprintf(std.stderr, "  %-8s %s\n", command.name, command.summary)
+	}
+	fmt.Fprintln(std.stderr, "Run parsego COMMAND -h for the flags of a command.")
+	return 2
+}
+
+func newFlagSet(std *streams, name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(std.stderr)
+	return flags
+}
+
+/*
+	Parses the flags found anywhere in args, returning the other arguments:
+	parsego parse calc.txt --grammar calc.peg works like
+	parsego parse --grammar calc.peg calc.txt.
+	Arguments after -- are never flags.
+*/
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for len(args) > 0 {
+		if err := flags.Parse(args); err == flag.ErrHelp {
+			return nil, err
+		} else if err != nil {
+			return nil, errUsage
+		}
+		rest := flags.Args()
+		if len(rest) < len(args) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	return positional, nil
+}
+
+/*
+	Fails with the usage of flags, for arguments the command does not take
+*/
+func noArguments(flags *flag.FlagSet, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	fmt.Fprintf(flags.Output(), "%s: unexpected argument %s\n", flags.Name(), args[0])
+	flags.Usage()
+	return errUsage
+}
+
+/*
+	The --grammar and --start flags of the commands
+*/
+type grammarFlags struct {
+	file  *string
+	start *string
+}
+
+func addGrammarFlags(flags *flag.FlagSet) *grammarFlags {
+	return &grammarFlags{
+		file:  flags.String("grammar", "", "grammar file, in PEG, or ABNF or EBNF by extension"),
+		start: flags.String("start", "", "start rule, the first rule of the grammar by default"),
+	}
+}
+
+/*
+	Loads the grammar and its start rule
+*/
+func (self *grammarFlags) load() (*pg.Grammar, pg.Parser, error) {
+	if *self.file == "" {
+		return nil, nil, errors.New("expected a --grammar file")
+	}
+	grammar, err := loadGrammar(*self.file)
+	if err != nil {
+		return nil, nil, err
+	}
+	rule, err := startRule(grammar, *self.start)
+	if err != nil {
+		return nil, nil, err
+	}
+	return grammar, rule, nil
+}
+
+/*
+	Loads a grammar file in the notation told by its extension,
+	.abnf, .ebnf or PEG for any other
+*/
+func loadGrammar(file string) (*pg.Grammar, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var grammar *pg.Grammar
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".abnf":
+		grammar, err = pg.LoadABNF(string(src))
+	case ".ebnf":
+		grammar, err = pg.LoadEBNF(string(src))
+	default:
+		grammar, err = pg.LoadPEG(string(src))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, strings.TrimPrefix(err.Error(), "pg: "))
+	}
+	return grammar, nil
+}
+
+/*
+	The named rule of grammar, the first one if name is empty
+*/
+func startRule(grammar *pg.Grammar, name string) (pg.Parser, error) {
+	if name == "" {
+		return grammar.Start(), nil
+	}
+	rule := grammar.Rule(name)
+	if rule == nil {
+		return nil, fmt.Errorf("no rule %s in the grammar", name)
+	}
+	return rule, nil
+}
+
+type input struct {
+	name string
+	text string
+}
+
+/*
+	The files named by args, the standard input for none or "-"
+*/
+func readInputs(stdin io.Reader, args []string) ([]input, error) {
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+	inputs := []input{}
+	for _, arg := range args {
+		var text []byte
+		var err error
+		if arg == "-" {
+			arg = "<stdin>"
+			text, err = io.ReadAll(stdin)
+		} else {
+			text, err = os.ReadFile(arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input{arg, string(text)})
+	}
+	return inputs, nil
+}
+
+/*
+	Writes a parse error located in the file, followed by the input line
+	it is on and a caret under its column
+*/
+func reportError(w io.Writer, in input, err error) {
+	parseErr, ok := err.(*pg.ParseError)
+	if !ok {
+		fmt.Fprintf(w, "%s: %s\n", in.name, err)
+		return
+	}
+	fmt.Fprintf(w, "%s:%s\n", in.name, err)
+	position := parseErr.Position
+	if position > len(in.text) {
+		position = len(in.text)
+	}
+	start := strings.LastIndexByte(in.text[:position], '\n') + 1
+	end := strings.IndexByte(in.text[start:], '\n')
+	if end < 0 {
+		end = len(in.text) - start
+	}
+	line := strings.TrimSuffix(in.text[start:start+end], "\r")
+	marker := []byte(in.text[start:position])
+	for i, c := range marker {
+		if c != '\t' {
+			marker[i] = ' '
+		}
+	}
+	fmt.Fprintf(w, "%s\n%s^\n", line, marker)
+}
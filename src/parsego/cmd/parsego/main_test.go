@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected outputs with the current ones")
+
+/*
+	Runs parsego with args and testdata/NAME.stdin, when there is one,
+	comparing its standard output and error with testdata/NAME.stdout
+	and testdata/NAME.stderr, missing when empty
+*/
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   string
+		status int
+	}{
+		{"parse", "parse --grammar testdata/calc.peg testdata/sum.input", 0},
+		{"parse_interleaved", "parse testdata/sum.input --format text --grammar testdata/calc.peg", 0},
+		{"parse_start", "parse --grammar testdata/calc.peg --start Number -- testdata/short.input", 1},
+		{"parse_error", "parse --grammar testdata/calc.peg testdata/bad.input testdata/sum.input", 1},
+		{"parse_usage", "parse --grammar testdata/calc.peg --colour testdata/sum.input", 2},
+		{"parse_no_grammar", "parse testdata/sum.input", 1},
+		{"parse_help", "parse -h", 0},
+		{"check", "check --grammar testdata/mistakes.peg", 1},
+		{"check_clean", "check --grammar testdata/calc.peg", 0},
+		{"check_arguments", "check --grammar testdata/calc.peg testdata/sum.input", 2},
+		{"trace", "trace testdata/short.input --grammar testdata/calc.peg", 0},
+		{"gen", "gen --grammar testdata/calc.peg --package calc --start Sum", 0},
+		{"gen_arguments", "gen --grammar testdata/calc.peg testdata/sum.input", 2},
+		{"repl", "repl --grammar testdata/calc.peg", 0},
+		{"repl_usage", "repl --grammar testdata/calc.peg testdata/sum.input", 2},
+		{"unknown", "unknown", 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base := filepath.Join("testdata", test.name)
+			stdin, err := os.ReadFile(base + ".stdin")
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			var stdout, stderr bytes.Buffer
+			std := &streams{bytes.NewReader(stdin), &stdout, &stderr}
+			if status := run(std, strings.Fields(test.args)); status != test.status {
+				t.Errorf("expected status %d, got %d\n%s", test.status, status, stderr.String())
+			}
+			expect(t, base+".stdout", stdout.Bytes())
+			expect(t, base+".stderr", stderr.Bytes())
+		})
+	}
+}
+
+func expect(t *testing.T, file string, actual []byte) {
+	if *update {
+		var err error
+		if len(actual) == 0 {
+			err = os.Remove(file)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = os.WriteFile(file, actual, 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("%s: expected\n%s\ngot\n%s", file, expected, actual)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"parsego/parser"
+	"parsego/parsetree"
+	"sort"
+	"strings"
+)
+
+var formats = map[string]pt.TreeWriter{
+	"text":  pt.WriteText,
+	"sexpr": pt.WriteSExpr,
+	"json":  pt.WriteJSON,
+	"xml":   pt.WriteXML,
+	"dot":   pt.WriteDOT,
+}
+
+/*
+	parsego parse --grammar calc.peg [--start Sum] [--format json] [FILE...]
+
+	Parses every file, or the standard input, writing its trees to the
+	standard output. Failed parses are reported with the line they stopped
+	at, the other files are still parsed.
+*/
+func parse(std *streams, args []string) error {
+	flags := newFlagSet(std, "parsego parse")
+	grammarFlags := addGrammarFlags(flags)
+	format := flags.String("format", "sexpr", "tree format: "+formatNames())
+	files, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	write, ok := formats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %s, expected one of %s", *format, formatNames())
+	}
+
+	grammar, rule, err := grammarFlags.load()
+	if err != nil {
+		return err
+	}
+	inputs, err := readInputs(std.stdin, files)
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, in := range inputs {
+		out, err := pg.Parse(rule, in.text)
+		if err != nil {
+			reportError(std.stderr, in, err)
+			failed = true
+			continue
+		}
+		for _, tree := range out {
+			if err := write(std.stdout, tree, grammar.Types); err != nil {
+				return err
+			}
+		}
+	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+/*
+	parsego trace --grammar calc.peg [--start Sum] [FILE...]
+
+	Parses like parsego parse, writing the rules tried instead of the trees,
+	see pg.NewPrintTracer.
+*/
+func trace(std *streams, args []string) error {
+	flags := newFlagSet(std, "parsego trace")
+	grammarFlags := addGrammarFlags(flags)
+	files, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	grammar, rule, err := grammarFlags.load()
+	if err != nil {
+		return err
+	}
+	inputs, err := readInputs(std.stdin, files)
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, in := range inputs {
+		state := pg.InitParser()
+		state.SetInput(in.text)
+		state.SetTracer(pg.NewPrintTracer(std.stdout, grammar.Types))
+		if _, err := pg.ParseWith(rule, state); err != nil {
+			reportError(std.stderr, in, err)
+			failed = true
+		}
+	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+func formatNames() string {
+	names := []string{}
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"parsego/parser"
+	"strings"
+)
+
+/*
+	parsego repl --grammar calc.peg [--start Sum] [--format text]
+
+	Reads lines from the standard input, parsing each one with the start
+	rule and writing its trees, its error and, with :trace, the rules tried.
+	Lines starting with : are commands, see replHelp.
+*/
+func repl(std *streams, args []string) error {
+	flags := newFlagSet(std, "parsego repl")
+	grammarFlags := addGrammarFlags(flags)
+	format := flags.String("format", "text", "tree format: "+formatNames())
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if err := noArguments(flags, args); err != nil {
+		return err
+	}
+	if _, ok := formats[*format]; !ok {
+		return fmt.Errorf("unknown format %s, expected one of %s", *format, formatNames())
+	}
+
+	grammar, rule, err := grammarFlags.load()
+	if err != nil {
+		return err
+	}
+	session := &replSession{
+		grammar: grammar,
+		rule:    rule,
+		start:   *grammarFlags.start,
+		format:  *format,
+		out:     std.stdout,
+	}
+	if session.start == "" {
+		session.start = grammar.Names[0]
+	}
+	session.run(std.stdin)
+	return nil
+}
+
+const replHelp = `Lines are parsed with the start rule, unless they are commands:
+  :def RULES     adds or replaces rules, in the notation of the grammar
+  :def           the same, with the rules on the next lines up to an empty one
+  :start RULE    parses with another rule
+  :rules         lists the rules
+  :check         reports grammar mistakes in the rules used by the start rule
+  :trace         turns tracing on or off
+  :format NAME   writes trees as ` + "%s" + `
+  :parse TEXT    parses TEXT, which may start with :
+  :help          shows this help
+  :quit          exits, like the end of the input
+`
+
+/*
+	The state of a repl, the rules being redefined in place
+	so that rule, the start rule, always uses the latest ones
+*/
+type replSession struct {
+	grammar *pg.Grammar
+	rule    pg.Parser
+	start   string
+	format  string
+	trace   bool
+	out     io.Writer
+}
+
+func (self *replSession) run(r io.Reader) {
+	lines := bufio.NewScanner(r)
+	for {
+		fmt.Fprint(self.out, "> ")
+		if !lines.Scan() {
+			fmt.Fprintln(self.out)
+			return
+		}
+		line := lines.Text()
+		if line == ":def" {
+			definitions := []string{}
+			for lines.Scan() && strings.TrimSpace(lines.Text()) != "" {
+				definitions = append(definitions, lines.Text())
+			}
+			line = ":def " + strings.Join(definitions, "\n")
+		}
+		if !self.execute(line) {
+			return
+		}
+	}
+}
+
+/*
+	Runs a line, telling whether to go on
+*/
+func (self *replSession) execute(line string) bool {
+	if !strings.HasPrefix(line, ":") {
+		self.parse(line)
+		return true
+	}
+	command, argument := line[1:], ""
+	if space := strings.IndexAny(command, " \t"); space >= 0 {
+		command, argument = command[:space], strings.TrimSpace(command[space+1:])
+	}
+	switch command {
+	case "def":
+		if err := self.grammar.Define(argument); err != nil {
+			fmt.Fprintln(self.out, strings.TrimPrefix(err.Error(), "pg: "))
+		}
+	case "start":
+		rule, err := startRule(self.grammar, argument)
+		if err != nil {
+			fmt.Fprintln(self.out, err)
+			break
+		}
+		self.rule, self.start = rule, argument
+	case "rules":
+		for _, name := range self.grammar.Names {
+			marker := " "
+			if name == self.start {
+				marker = "*"
+			}
+			fmt.Fprintf(self.out, "%s %s\n", marker, name)
+		}
+	case "check":
+		issues := pg.Check(pg.Describe(self.rule), self.grammar.Types)
+		for _, issue := range issues {
+			fmt.Fprintln(self.out, issue)
+		}
+		if len(issues) == 0 {
+			fmt.Fprintln(self.out, "no issues")
+		}
+	case "trace":
+		self.trace = !self.trace
+		if self.trace {
+			fmt.Fprintln(self.out, "tracing on")
+		} else {
+			fmt.Fprintln(self.out, "tracing off")
+		}
+	case "format":
+		if _, ok := formats[argument]; !ok {
+			fmt.Fprintf(self.out, "unknown format %s, expected one of %s\n", argument, formatNames())
+			break
+		}
+		self.format = argument
+	case "parse":
+		self.parse(argument)
+	case "help":
+		fmt.Fprintf(self.out, replHelp, formatNames())
+	case "quit":
+		return false
+	default:
+		fmt.Fprintf(self.out, "unknown command :%s, see :help\n", command)
+	}
+	return true
+}
+
+func (self *replSession) parse(text string) {
+	state := pg.InitParser()
+	state.SetInput(text)
+	if self.trace {
+		state.SetTracer(pg.NewPrintTracer(self.out, self.grammar.Types))
+	}
+	out, err := pg.ParseWith(self.rule, state)
+	if err != nil {
+		reportError(self.out, input{"input", text}, err)
+		return
+	}
+	for _, tree := range out {
+		formats[self.format](self.out, tree, self.grammar.Types)
+	}
+}
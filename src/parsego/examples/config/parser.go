@@ -0,0 +1,810 @@
+// Code generated by parsego gen. DO NOT EDIT.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type ParseTree struct {
+	Value    []byte
+	Type     int
+	Children []*ParseTree
+	Position InputPosition
+}
+
+type InputPosition struct {
+	StartPosition int
+	EndPosition   int
+	StartLine     int
+	EndLine       int
+}
+
+type ParseError struct {
+	Position int
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+type parser struct {
+	input    []byte
+	position int
+	line     int
+	farthest int
+}
+
+func (p *parser) next() (byte, bool) {
+	if p.position > p.farthest {
+		p.farthest = p.position
+	}
+	if p.position >= len(p.input) {
+		return 0, false
+	}
+	c := p.input[p.position]
+	p.position++
+	if c == '\n' {
+		p.line++
+	}
+	return c, true
+}
+
+func (p *parser) literal(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if c, ok := p.next(); !ok || c != text[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) leaf(start int) []*ParseTree {
+	return []*ParseTree{{Value: p.input[start:p.position:p.position]}}
+}
+
+func (p *parser) node(nodeType, position, line int, out []*ParseTree) []*ParseTree {
+	node := &ParseTree{Type: nodeType}
+	node.Position = InputPosition{position, p.position, line, p.line}
+	if len(out) == 1 && out[0].Type == 0 {
+		node.Value = out[0].Value
+	} else if len(out) > 0 {
+		node.Children = out
+	}
+	return []*ParseTree{node}
+}
+
+func concat(a, b []*ParseTree) []*ParseTree {
+	if len(a) == 1 && len(b) == 1 && a[0].Type == 0 && b[0].Type == 0 {
+		a[0].Value = concatBytes(a[0].Value, b[0].Value)
+		return a
+	}
+	return append(a, b...)
+}
+
+// leaves are slices of the input capped at their end, merges copy them once
+// to a buffer of their own and then grow it in place
+func concatBytes(a, b []byte) []byte {
+	if len(a) == 0 {
+		return b
+	}
+	return append(a, b...)
+}
+
+var NodeTypes = map[int]string{
+	0: "?",
+	1: "File",
+	2: "Line",
+	3: "Comment",
+	4: "Entry",
+	5: "Key",
+	6: "Value",
+	7: "Quoted",
+	8: "Bare",
+	9: "Blank",
+}
+
+func Parse(input string) ([]*ParseTree, error) {
+	p := &parser{input: []byte(input), line: 1}
+	out, ok := p.e4()
+	if ok && p.position == len(p.input) {
+		return out, nil
+	}
+	position := p.farthest
+	if ok && p.position > position {
+		position = p.position
+	}
+	err := &ParseError{Position: position}
+	if position >= len(p.input) {
+		position = len(p.input)
+		err.Message = "unexpected end of input"
+	} else {
+		err.Message = fmt.Sprintf("unexpected %q", p.input[position])
+	}
+	err.Line = bytes.Count(p.input[:position], []byte{'\n'}) + 1
+	err.Column = position - bytes.LastIndexByte(p.input[:position], '\n')
+	return out, err
+}
+
+// Line*
+func (p *parser) e0() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e9()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// .
+func (p *parser) e1() ([]*ParseTree, bool) {
+	_, ok := p.next()
+	if !ok {
+		return nil, false
+	}
+	return p.leaf(p.position - 1), true
+}
+
+// !.
+func (p *parser) e2() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	_, ok := p.e1()
+	p.position, p.line = position, line
+	return nil, !ok
+}
+
+// Line* !.
+func (p *parser) e3() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e0()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e2()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// File <- Line* !.
+func (p *parser) e4() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e3()
+	if !ok {
+		return nil, false
+	}
+	return p.node(1, position, line, out), true
+}
+
+// Comment
+func (p *parser) e5() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e17()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Entry
+func (p *parser) e6() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e22()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Blank
+func (p *parser) e7() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e53()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Comment / Entry / Blank
+func (p *parser) e8() ([]*ParseTree, bool) {
+	if out, ok := p.e5(); ok {
+		return out, true
+	}
+	if out, ok := p.e6(); ok {
+		return out, true
+	}
+	if out, ok := p.e7(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// Line <- Comment / Entry / Blank
+func (p *parser) e9() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e8()
+	if !ok {
+		return nil, false
+	}
+	return p.node(2, position, line, out), true
+}
+
+// "#"
+func (p *parser) e10() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("#") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "\n"
+func (p *parser) e11() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("\n") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// !"\n"
+func (p *parser) e12() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	_, ok := p.e11()
+	p.position, p.line = position, line
+	return nil, !ok
+}
+
+// !"\n" .
+func (p *parser) e13() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e12()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e1()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// (!"\n" .)*
+func (p *parser) e14() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e13()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// "\n"?
+func (p *parser) e15() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	if out, ok := p.e11(); ok {
+		return out, true
+	}
+	p.position, p.line = position, line
+	return nil, true
+}
+
+// "#" (!"\n" .)* "\n"?
+func (p *parser) e16() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e10()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e14()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e15()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// Comment <- "#" (!"\n" .)* "\n"?
+func (p *parser) e17() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e16()
+	if !ok {
+		return nil, false
+	}
+	return p.node(3, position, line, out), true
+}
+
+// "="
+func (p *parser) e18() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("=") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// Value?
+func (p *parser) e19() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	if out, ok := p.e34(); ok {
+		return out, true
+	}
+	p.position, p.line = position, line
+	return nil, true
+}
+
+// Comment?
+func (p *parser) e20() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	if out, ok := p.e17(); ok {
+		return out, true
+	}
+	p.position, p.line = position, line
+	return nil, true
+}
+
+// Key _ "=" _ Value? _ Comment? "\n"?
+func (p *parser) e21() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e27()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e30()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e18()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e30()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e19()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e30()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e20()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e15()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// Entry <- Key _ "=" _ Value? _ Comment? "\n"?
+func (p *parser) e22() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e21()
+	if !ok {
+		return nil, false
+	}
+	return p.node(4, position, line, out), true
+}
+
+// [a-zA-Z_]
+func (p *parser) e23() ([]*ParseTree, bool) {
+	c, ok := p.next()
+	if !ok || !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_') {
+		return nil, false
+	}
+	return p.leaf(p.position - 1), true
+}
+
+// [a-zA-Z0-9_.]
+func (p *parser) e24() ([]*ParseTree, bool) {
+	c, ok := p.next()
+	if !ok || !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.') {
+		return nil, false
+	}
+	return p.leaf(p.position - 1), true
+}
+
+// [a-zA-Z0-9_.]*
+func (p *parser) e25() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e24()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// [a-zA-Z_] [a-zA-Z0-9_.]*
+func (p *parser) e26() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e23()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e25()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// Key <- [a-zA-Z_] [a-zA-Z0-9_.]*
+func (p *parser) e27() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e26()
+	if !ok {
+		return nil, false
+	}
+	return p.node(5, position, line, out), true
+}
+
+// [ \t]
+func (p *parser) e28() ([]*ParseTree, bool) {
+	c, ok := p.next()
+	if !ok || !(c == ' ' || c == '\t') {
+		return nil, false
+	}
+	return p.leaf(p.position - 1), true
+}
+
+// [ \t]*
+func (p *parser) e29() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e28()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// [ \t]*
+func (p *parser) e30() ([]*ParseTree, bool) {
+	_, ok := p.e29()
+	return nil, ok
+}
+
+// Quoted
+func (p *parser) e31() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e40()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Bare
+func (p *parser) e32() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e51()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Quoted / Bare
+func (p *parser) e33() ([]*ParseTree, bool) {
+	if out, ok := p.e31(); ok {
+		return out, true
+	}
+	if out, ok := p.e32(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// Value <- Quoted / Bare
+func (p *parser) e34() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e33()
+	if !ok {
+		return nil, false
+	}
+	return p.node(6, position, line, out), true
+}
+
+// ["]
+func (p *parser) e35() ([]*ParseTree, bool) {
+	c, ok := p.next()
+	if !ok || !(c == '"') {
+		return nil, false
+	}
+	return p.leaf(p.position - 1), true
+}
+
+// !["]
+func (p *parser) e36() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	_, ok := p.e35()
+	p.position, p.line = position, line
+	return nil, !ok
+}
+
+// !["] .
+func (p *parser) e37() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e36()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e1()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// (!["] .)*
+func (p *parser) e38() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e37()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// ["] (!["] .)* ["]
+func (p *parser) e39() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e35()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e38()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e35()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// Quoted <- ["] (!["] .)* ["]
+func (p *parser) e40() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e39()
+	if !ok {
+		return nil, false
+	}
+	return p.node(7, position, line, out), true
+}
+
+// [\n#]
+func (p *parser) e41() ([]*ParseTree, bool) {
+	c, ok := p.next()
+	if !ok || !(c == '\n' || c == '#') {
+		return nil, false
+	}
+	return p.leaf(p.position - 1), true
+}
+
+// ![\n#]
+func (p *parser) e42() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	_, ok := p.e41()
+	p.position, p.line = position, line
+	return nil, !ok
+}
+
+// ![\n#] .
+func (p *parser) e43() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e42()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e1()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// (![\n#] .)+
+func (p *parser) e44() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e43()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e43()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// "\n"
+func (p *parser) e45() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e11()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "#"
+func (p *parser) e46() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e10()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// !.
+func (p *parser) e47() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e2()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "\n" / "#" / !.
+func (p *parser) e48() ([]*ParseTree, bool) {
+	if out, ok := p.e45(); ok {
+		return out, true
+	}
+	if out, ok := p.e46(); ok {
+		return out, true
+	}
+	if out, ok := p.e47(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// &("\n" / "#" / !.)
+func (p *parser) e49() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	_, ok := p.e48()
+	p.position, p.line = position, line
+	return nil, ok
+}
+
+// (![\n#] .)+ &("\n" / "#" / !.)
+func (p *parser) e50() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e44()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e49()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// Bare <- (![\n#] .)+ &("\n" / "#" / !.)
+func (p *parser) e51() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e50()
+	if !ok {
+		return nil, false
+	}
+	return p.node(8, position, line, out), true
+}
+
+// _ "\n"
+func (p *parser) e52() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e30()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e11()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// Blank <- _ "\n"
+func (p *parser) e53() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e52()
+	if !ok {
+		return nil, false
+	}
+	return p.node(9, position, line, out), true
+}
@@ -0,0 +1,3076 @@
+// Code generated by parsego gen. DO NOT EDIT.
+
+package program
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type ParseTree struct {
+	Value    []byte
+	Type     int
+	Children []*ParseTree
+	Position InputPosition
+}
+
+type InputPosition struct {
+	StartPosition int
+	EndPosition   int
+	StartLine     int
+	EndLine       int
+}
+
+type ParseError struct {
+	Position int
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+type parser struct {
+	input    []byte
+	position int
+	line     int
+	farthest int
+}
+
+func (p *parser) next() (byte, bool) {
+	if p.position > p.farthest {
+		p.farthest = p.position
+	}
+	if p.position >= len(p.input) {
+		return 0, false
+	}
+	c := p.input[p.position]
+	p.position++
+	if c == '\n' {
+		p.line++
+	}
+	return c, true
+}
+
+func (p *parser) literal(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if c, ok := p.next(); !ok || c != text[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) leaf(start int) []*ParseTree {
+	return []*ParseTree{{Value: p.input[start:p.position:p.position]}}
+}
+
+func (p *parser) node(nodeType, position, line int, out []*ParseTree) []*ParseTree {
+	node := &ParseTree{Type: nodeType}
+	node.Position = InputPosition{position, p.position, line, p.line}
+	if len(out) == 1 && out[0].Type == 0 {
+		node.Value = out[0].Value
+	} else if len(out) > 0 {
+		node.Children = out
+	}
+	return []*ParseTree{node}
+}
+
+func concat(a, b []*ParseTree) []*ParseTree {
+	if len(a) == 1 && len(b) == 1 && a[0].Type == 0 && b[0].Type == 0 {
+		a[0].Value = concatBytes(a[0].Value, b[0].Value)
+		return a
+	}
+	return append(a, b...)
+}
+
+// leaves are slices of the input capped at their end, merges copy them once
+// to a buffer of their own and then grow it in place
+func concatBytes(a, b []byte) []byte {
+	if len(a) == 0 {
+		return b
+	}
+	return append(a, b...)
+}
+
+var NodeTypes = map[int]string{
+	0:  "?",
+	1:  "IDENTIFIER",
+	2:  "NUMBER_LITERAL",
+	3:  "STRING_LITERAL",
+	4:  "BOOL_LITERAL",
+	6:  "ASSIGNMENT",
+	7:  "EXPRESSION",
+	8:  "SUM",
+	9:  "PRODUCT",
+	11: "FOREACH",
+	12: "FOR",
+	13: "FOR_INIT",
+	14: "FOR_CONDITION",
+	15: "FOR_STEP",
+	16: "BLOCK",
+	17: "IFTHEN",
+	18: "IFTHENELSE",
+	19: "SWITCH",
+	20: "CASE",
+	21: "CASE_ELSE",
+	22: "L_COMPARISON",
+	23: "L_E_COMPARISON",
+	24: "G_COMPARISON",
+	25: "G_E_COMPARISON",
+	26: "E_COMPARISON",
+	27: "BREAK",
+	28: "CONTINUE",
+	29: "RETURN",
+	30: "OR_EXPRESSION",
+	31: "AND_EXPRESSION",
+	32: "FUNCTION_CALL",
+	33: "FUNCTION_DEFINITION",
+	34: "PROGRAM",
+}
+
+func Parse(input string) ([]*ParseTree, error) {
+	p := &parser{input: []byte(input), line: 1}
+	out, ok := p.e149()
+	if ok && p.position == len(p.input) {
+		return out, nil
+	}
+	position := p.farthest
+	if ok && p.position > position {
+		position = p.position
+	}
+	err := &ParseError{Position: position}
+	if position >= len(p.input) {
+		position = len(p.input)
+		err.Message = "unexpected end of input"
+	} else {
+		err.Message = fmt.Sprintf("unexpected %q", p.input[position])
+	}
+	err.Line = bytes.Count(p.input[:position], []byte{'\n'}) + 1
+	err.Column = position - bytes.LastIndexByte(p.input[:position], '\n')
+	return out, err
+}
+
+// [\t-\n\014-\r ]
+func (p *parser) e0() ([]*ParseTree, bool) {
+	c, ok := p.next()
+	if !ok || !(c >= '\t' && c <= '\n' || c >= '\f' && c <= '\r' || c == ' ') {
+		return nil, false
+	}
+	return p.leaf(p.position - 1), true
+}
+
+// [\t-\n\014-\r ]
+func (p *parser) e1() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e0()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// [\t-\n\014-\r ]*
+func (p *parser) e2() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e1()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// [\t-\n\014-\r ]*
+func (p *parser) e3() ([]*ParseTree, bool) {
+	_, ok := p.e2()
+	return nil, ok
+}
+
+// "func"
+func (p *parser) e4() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("func") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "func"
+func (p *parser) e5() ([]*ParseTree, bool) {
+	_, ok := p.e4()
+	return nil, ok
+}
+
+// [a-zA-Z]
+func (p *parser) e6() ([]*ParseTree, bool) {
+	c, ok := p.next()
+	if !ok || !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+		return nil, false
+	}
+	return p.leaf(p.position - 1), true
+}
+
+// [a-zA-Z]
+func (p *parser) e7() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e6()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// [0-9]
+func (p *parser) e8() ([]*ParseTree, bool) {
+	c, ok := p.next()
+	if !ok || !(c >= '0' && c <= '9') {
+		return nil, false
+	}
+	return p.leaf(p.position - 1), true
+}
+
+// [0-9]
+func (p *parser) e9() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e8()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// [a-zA-Z] / [0-9]
+func (p *parser) e10() ([]*ParseTree, bool) {
+	if out, ok := p.e7(); ok {
+		return out, true
+	}
+	if out, ok := p.e9(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// ([a-zA-Z] / [0-9])*
+func (p *parser) e11() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e10()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// [a-zA-Z] ([a-zA-Z] / [0-9])*
+func (p *parser) e12() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e6()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e11()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// IDENTIFIER <- [a-zA-Z] ([a-zA-Z] / [0-9])*
+func (p *parser) e13() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e12()
+	if !ok {
+		return nil, false
+	}
+	return p.node(1, position, line, out), true
+}
+
+// "("
+func (p *parser) e14() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("(") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "("
+func (p *parser) e15() ([]*ParseTree, bool) {
+	_, ok := p.e14()
+	return nil, ok
+}
+
+// "(" [\t-\n\014-\r ]*
+func (p *parser) e16() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e15()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// ","
+func (p *parser) e17() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal(",") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// ","
+func (p *parser) e18() ([]*ParseTree, bool) {
+	_, ok := p.e17()
+	return nil, ok
+}
+
+// IDENTIFIER [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* NamedParamsList1
+func (p *parser) e19() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e13()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e18()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e23()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// IDENTIFIER [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* NamedParamsList1
+func (p *parser) e20() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e19()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// IDENTIFIER
+func (p *parser) e21() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e13()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// IDENTIFIER [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* NamedParamsList1 / I...
+func (p *parser) e22() ([]*ParseTree, bool) {
+	if out, ok := p.e20(); ok {
+		return out, true
+	}
+	if out, ok := p.e21(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// [\t-\n\014-\r ]* (IDENTIFIER [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* Na...
+func (p *parser) e23() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e22()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// [\t-\n\014-\r ]* (IDENTIFIER [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* Na...
+func (p *parser) e24() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e23()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// ""
+func (p *parser) e25() ([]*ParseTree, bool) {
+	return nil, true
+}
+
+// ""
+func (p *parser) e26() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e25()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// [\t-\n\014-\r ]* (IDENTIFIER [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* Na...
+func (p *parser) e27() ([]*ParseTree, bool) {
+	if out, ok := p.e24(); ok {
+		return out, true
+	}
+	if out, ok := p.e26(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (IDENTIFIER [\t-\n\014-\r ]* "," [...
+func (p *parser) e28() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e27()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// [\t-\n\014-\r ]*
+func (p *parser) e29() ([]*ParseTree, bool) {
+	_, ok := p.e3()
+	return nil, ok
+}
+
+// ")"
+func (p *parser) e30() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal(")") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// [\t-\n\014-\r ]* ")"
+func (p *parser) e31() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e29()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e30()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// "(" [\t-\n\014-\r ]* [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (IDENTIFIER [...
+func (p *parser) e32() ([]*ParseTree, bool) {
+	if _, ok := p.e16(); !ok {
+		return nil, false
+	}
+	out, ok := p.e28()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e31(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// "func" [\t-\n\014-\r ]* IDENTIFIER "(" [\t-\n\014-\r ]* [\t-\n\014-\r...
+func (p *parser) e33() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e5()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e13()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e32()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e155()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// FUNCTION_DEFINITION <- "func" [\t-\n\014-\r ]* IDENTIFIER "(" [\t-\n\...
+func (p *parser) e34() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e33()
+	if !ok {
+		return nil, false
+	}
+	return p.node(33, position, line, out), true
+}
+
+// FUNCTION_DEFINITION
+func (p *parser) e35() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e34()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Expression [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* ParamsList1
+func (p *parser) e36() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e141()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e18()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e40()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// Expression [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* ParamsList1
+func (p *parser) e37() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e36()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Expression
+func (p *parser) e38() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e141()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Expression [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* ParamsList1 / Expres...
+func (p *parser) e39() ([]*ParseTree, bool) {
+	if out, ok := p.e37(); ok {
+		return out, true
+	}
+	if out, ok := p.e38(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// [\t-\n\014-\r ]* (Expression [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* Pa...
+func (p *parser) e40() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e39()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// [\t-\n\014-\r ]* (Expression [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* Pa...
+func (p *parser) e41() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e40()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// [\t-\n\014-\r ]* (Expression [\t-\n\014-\r ]* "," [\t-\n\014-\r ]* Pa...
+func (p *parser) e42() ([]*ParseTree, bool) {
+	if out, ok := p.e41(); ok {
+		return out, true
+	}
+	if out, ok := p.e26(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (Expression [\t-\n\014-\r ]* "," [...
+func (p *parser) e43() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e42()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// "(" [\t-\n\014-\r ]* [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (Expression [...
+func (p *parser) e44() ([]*ParseTree, bool) {
+	if _, ok := p.e16(); !ok {
+		return nil, false
+	}
+	out, ok := p.e43()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e31(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// IDENTIFIER "(" [\t-\n\014-\r ]* [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (E...
+func (p *parser) e45() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e13()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e44()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// FUNCTION_CALL <- IDENTIFIER "(" [\t-\n\014-\r ]* [\t-\n\014-\r ]* ([\...
+func (p *parser) e46() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e45()
+	if !ok {
+		return nil, false
+	}
+	return p.node(32, position, line, out), true
+}
+
+// FUNCTION_CALL
+func (p *parser) e47() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e46()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// ControlStatement
+func (p *parser) e48() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e226()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "="
+func (p *parser) e49() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("=") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "="
+func (p *parser) e50() ([]*ParseTree, bool) {
+	_, ok := p.e49()
+	return nil, ok
+}
+
+// [0-9]+
+func (p *parser) e51() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e8()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e8()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// NUMBER_LITERAL <- [0-9]+
+func (p *parser) e52() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e51()
+	if !ok {
+		return nil, false
+	}
+	return p.node(2, position, line, out), true
+}
+
+// NUMBER_LITERAL
+func (p *parser) e53() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e52()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "\""
+func (p *parser) e54() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("\"") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "\""
+func (p *parser) e55() ([]*ParseTree, bool) {
+	_, ok := p.e54()
+	return nil, ok
+}
+
+// "\"" ([a-zA-Z] / [0-9])* "\""
+func (p *parser) e56() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e55()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e11()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e55()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// STRING_LITERAL <- "\"" ([a-zA-Z] / [0-9])* "\""
+func (p *parser) e57() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e56()
+	if !ok {
+		return nil, false
+	}
+	return p.node(3, position, line, out), true
+}
+
+// STRING_LITERAL
+func (p *parser) e58() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e57()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "true"
+func (p *parser) e59() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("true") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "true"
+func (p *parser) e60() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e59()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "false"
+func (p *parser) e61() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("false") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "false"
+func (p *parser) e62() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e61()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "true" / "false"
+func (p *parser) e63() ([]*ParseTree, bool) {
+	if out, ok := p.e60(); ok {
+		return out, true
+	}
+	if out, ok := p.e62(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// BOOL_LITERAL <- "true" / "false"
+func (p *parser) e64() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e63()
+	if !ok {
+		return nil, false
+	}
+	return p.node(4, position, line, out), true
+}
+
+// BOOL_LITERAL
+func (p *parser) e65() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e64()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// NUMBER_LITERAL / STRING_LITERAL / BOOL_LITERAL
+func (p *parser) e66() ([]*ParseTree, bool) {
+	if out, ok := p.e53(); ok {
+		return out, true
+	}
+	if out, ok := p.e58(); ok {
+		return out, true
+	}
+	if out, ok := p.e65(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// NUMBER_LITERAL / STRING_LITERAL / BOOL_LITERAL
+func (p *parser) e67() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e66()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "(" [\t-\n\014-\r ]* Expression [\t-\n\014-\r ]* ")"
+func (p *parser) e68() ([]*ParseTree, bool) {
+	if _, ok := p.e16(); !ok {
+		return nil, false
+	}
+	out, ok := p.e141()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e31(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// "(" [\t-\n\014-\r ]* Expression [\t-\n\014-\r ]* ")"
+func (p *parser) e69() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e68()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// FUNCTION_CALL / NUMBER_LITERAL / STRING_LITERAL / BOOL_LITERAL / IDEN...
+func (p *parser) e70() ([]*ParseTree, bool) {
+	if out, ok := p.e47(); ok {
+		return out, true
+	}
+	if out, ok := p.e67(); ok {
+		return out, true
+	}
+	if out, ok := p.e21(); ok {
+		return out, true
+	}
+	if out, ok := p.e69(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// "*"
+func (p *parser) e71() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("*") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "*"
+func (p *parser) e72() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e71()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "/"
+func (p *parser) e73() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("/") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "/"
+func (p *parser) e74() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e73()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "*" / "/"
+func (p *parser) e75() ([]*ParseTree, bool) {
+	if out, ok := p.e72(); ok {
+		return out, true
+	}
+	if out, ok := p.e74(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// "*" / "/"
+func (p *parser) e76() ([]*ParseTree, bool) {
+	_, ok := p.e75()
+	return nil, ok
+}
+
+// ("*" / "/") [\t-\n\014-\r ]* (FUNCTION_CALL / NUMBER_LITERAL / STRING...
+func (p *parser) e77() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e76()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e70()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// [\t-\n\014-\r ]* ("*" / "/") [\t-\n\014-\r ]* (FUNCTION_CALL / NUMBER...
+func (p *parser) e78() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e77()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// (FUNCTION_CALL / NUMBER_LITERAL / STRING_LITERAL / BOOL_LITERAL / IDE...
+func (p *parser) e79() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e70()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e78()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// PRODUCT <- (FUNCTION_CALL / NUMBER_LITERAL / STRING_LITERAL / BOOL_LI...
+func (p *parser) e80() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e79()
+	if !ok {
+		return nil, false
+	}
+	return p.node(9, position, line, out), true
+}
+
+// PRODUCT
+func (p *parser) e81() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e80()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// [\t-\n\014-\r ]* (FUNCTION_CALL / NUMBER_LITERAL / STRING_LITERAL / B...
+func (p *parser) e82() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e70()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// [\t-\n\014-\r ]* (FUNCTION_CALL / NUMBER_LITERAL / STRING_LITERAL / B...
+func (p *parser) e83() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e82()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// PRODUCT / [\t-\n\014-\r ]* (FUNCTION_CALL / NUMBER_LITERAL / STRING_L...
+func (p *parser) e84() ([]*ParseTree, bool) {
+	if out, ok := p.e81(); ok {
+		return out, true
+	}
+	if out, ok := p.e83(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// "+"
+func (p *parser) e85() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("+") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "+"
+func (p *parser) e86() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e85()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "-"
+func (p *parser) e87() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("-") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "-"
+func (p *parser) e88() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e87()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "+" / "-"
+func (p *parser) e89() ([]*ParseTree, bool) {
+	if out, ok := p.e86(); ok {
+		return out, true
+	}
+	if out, ok := p.e88(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// "+" / "-"
+func (p *parser) e90() ([]*ParseTree, bool) {
+	_, ok := p.e89()
+	return nil, ok
+}
+
+// ("+" / "-") [\t-\n\014-\r ]* Product
+func (p *parser) e91() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e90()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e84()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// [\t-\n\014-\r ]* ("+" / "-") [\t-\n\014-\r ]* Product [\t-\n\014-\r ]*
+func (p *parser) e92() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e91()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// Product [\t-\n\014-\r ]* ("+" / "-") [\t-\n\014-\r ]* Product [\t-\n\...
+func (p *parser) e93() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e84()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e92()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// SUM <- Product [\t-\n\014-\r ]* ("+" / "-") [\t-\n\014-\r ]* Product ...
+func (p *parser) e94() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e93()
+	if !ok {
+		return nil, false
+	}
+	return p.node(8, position, line, out), true
+}
+
+// SUM
+func (p *parser) e95() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e94()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// [\t-\n\014-\r ]* Product [\t-\n\014-\r ]*
+func (p *parser) e96() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e84()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// [\t-\n\014-\r ]* Product [\t-\n\014-\r ]*
+func (p *parser) e97() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e96()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// SUM / [\t-\n\014-\r ]* Product [\t-\n\014-\r ]*
+func (p *parser) e98() ([]*ParseTree, bool) {
+	if out, ok := p.e95(); ok {
+		return out, true
+	}
+	if out, ok := p.e97(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// "<"
+func (p *parser) e99() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("<") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "<"
+func (p *parser) e100() ([]*ParseTree, bool) {
+	_, ok := p.e99()
+	return nil, ok
+}
+
+// Sum [\t-\n\014-\r ]* "<" [\t-\n\014-\r ]* Sum
+func (p *parser) e101() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e100()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// L_COMPARISON <- Sum [\t-\n\014-\r ]* "<" [\t-\n\014-\r ]* Sum
+func (p *parser) e102() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e101()
+	if !ok {
+		return nil, false
+	}
+	return p.node(22, position, line, out), true
+}
+
+// L_COMPARISON
+func (p *parser) e103() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e102()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "<="
+func (p *parser) e104() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("<=") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "<="
+func (p *parser) e105() ([]*ParseTree, bool) {
+	_, ok := p.e104()
+	return nil, ok
+}
+
+// Sum [\t-\n\014-\r ]* "<=" [\t-\n\014-\r ]* Sum
+func (p *parser) e106() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e105()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// L_E_COMPARISON <- Sum [\t-\n\014-\r ]* "<=" [\t-\n\014-\r ]* Sum
+func (p *parser) e107() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e106()
+	if !ok {
+		return nil, false
+	}
+	return p.node(23, position, line, out), true
+}
+
+// L_E_COMPARISON
+func (p *parser) e108() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e107()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// ">"
+func (p *parser) e109() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal(">") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// ">"
+func (p *parser) e110() ([]*ParseTree, bool) {
+	_, ok := p.e109()
+	return nil, ok
+}
+
+// Sum [\t-\n\014-\r ]* ">" [\t-\n\014-\r ]* Sum
+func (p *parser) e111() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e110()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// G_COMPARISON <- Sum [\t-\n\014-\r ]* ">" [\t-\n\014-\r ]* Sum
+func (p *parser) e112() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e111()
+	if !ok {
+		return nil, false
+	}
+	return p.node(24, position, line, out), true
+}
+
+// G_COMPARISON
+func (p *parser) e113() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e112()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// ">="
+func (p *parser) e114() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal(">=") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// ">="
+func (p *parser) e115() ([]*ParseTree, bool) {
+	_, ok := p.e114()
+	return nil, ok
+}
+
+// Sum [\t-\n\014-\r ]* ">=" [\t-\n\014-\r ]* Sum
+func (p *parser) e116() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e115()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// G_E_COMPARISON <- Sum [\t-\n\014-\r ]* ">=" [\t-\n\014-\r ]* Sum
+func (p *parser) e117() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e116()
+	if !ok {
+		return nil, false
+	}
+	return p.node(25, position, line, out), true
+}
+
+// G_E_COMPARISON
+func (p *parser) e118() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e117()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "=="
+func (p *parser) e119() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("==") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "=="
+func (p *parser) e120() ([]*ParseTree, bool) {
+	_, ok := p.e119()
+	return nil, ok
+}
+
+// Sum [\t-\n\014-\r ]* "==" [\t-\n\014-\r ]* Sum
+func (p *parser) e121() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e120()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e98()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// E_COMPARISON <- Sum [\t-\n\014-\r ]* "==" [\t-\n\014-\r ]* Sum
+func (p *parser) e122() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e121()
+	if !ok {
+		return nil, false
+	}
+	return p.node(26, position, line, out), true
+}
+
+// E_COMPARISON
+func (p *parser) e123() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e122()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Sum
+func (p *parser) e124() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e98()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// L_COMPARISON / L_E_COMPARISON / G_COMPARISON / G_E_COMPARISON / E_COM...
+func (p *parser) e125() ([]*ParseTree, bool) {
+	if out, ok := p.e103(); ok {
+		return out, true
+	}
+	if out, ok := p.e108(); ok {
+		return out, true
+	}
+	if out, ok := p.e113(); ok {
+		return out, true
+	}
+	if out, ok := p.e118(); ok {
+		return out, true
+	}
+	if out, ok := p.e123(); ok {
+		return out, true
+	}
+	if out, ok := p.e124(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// "&&"
+func (p *parser) e126() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("&&") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "&&"
+func (p *parser) e127() ([]*ParseTree, bool) {
+	_, ok := p.e126()
+	return nil, ok
+}
+
+// Comparison [\t-\n\014-\r ]* "&&" [\t-\n\014-\r ]* Comparison
+func (p *parser) e128() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e125()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e127()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e125()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// AND_EXPRESSION <- Comparison [\t-\n\014-\r ]* "&&" [\t-\n\014-\r ]* C...
+func (p *parser) e129() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e128()
+	if !ok {
+		return nil, false
+	}
+	return p.node(31, position, line, out), true
+}
+
+// AND_EXPRESSION
+func (p *parser) e130() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e129()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// Comparison
+func (p *parser) e131() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e125()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// AND_EXPRESSION / Comparison
+func (p *parser) e132() ([]*ParseTree, bool) {
+	if out, ok := p.e130(); ok {
+		return out, true
+	}
+	if out, ok := p.e131(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// "||"
+func (p *parser) e133() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("||") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "||"
+func (p *parser) e134() ([]*ParseTree, bool) {
+	_, ok := p.e133()
+	return nil, ok
+}
+
+// (AND_EXPRESSION / Comparison) [\t-\n\014-\r ]* "||" [\t-\n\014-\r ]* ...
+func (p *parser) e135() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e132()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e134()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e132()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// OR_EXPRESSION <- (AND_EXPRESSION / Comparison) [\t-\n\014-\r ]* "||" ...
+func (p *parser) e136() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e135()
+	if !ok {
+		return nil, false
+	}
+	return p.node(30, position, line, out), true
+}
+
+// OR_EXPRESSION
+func (p *parser) e137() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e136()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// AND_EXPRESSION / Comparison
+func (p *parser) e138() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e132()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// OR_EXPRESSION / AND_EXPRESSION / Comparison
+func (p *parser) e139() ([]*ParseTree, bool) {
+	if out, ok := p.e137(); ok {
+		return out, true
+	}
+	if out, ok := p.e138(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// [\t-\n\014-\r ]* (OR_EXPRESSION / AND_EXPRESSION / Comparison) [\t-\n...
+func (p *parser) e140() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e139()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// EXPRESSION <- [\t-\n\014-\r ]* (OR_EXPRESSION / AND_EXPRESSION / Comp...
+func (p *parser) e141() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e140()
+	if !ok {
+		return nil, false
+	}
+	return p.node(7, position, line, out), true
+}
+
+// IDENTIFIER [\t-\n\014-\r ]* "=" [\t-\n\014-\r ]* EXPRESSION
+func (p *parser) e142() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e13()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e50()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e141()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// [\t-\n\014-\r ]* IDENTIFIER [\t-\n\014-\r ]* "=" [\t-\n\014-\r ]* EXP...
+func (p *parser) e143() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e142()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// ASSIGNMENT <- [\t-\n\014-\r ]* IDENTIFIER [\t-\n\014-\r ]* "=" [\t-\n...
+func (p *parser) e144() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e143()
+	if !ok {
+		return nil, false
+	}
+	return p.node(6, position, line, out), true
+}
+
+// ASSIGNMENT
+func (p *parser) e145() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e144()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// FUNCTION_DEFINITION / FUNCTION_CALL / ControlStatement / ASSIGNMENT
+func (p *parser) e146() ([]*ParseTree, bool) {
+	if out, ok := p.e35(); ok {
+		return out, true
+	}
+	if out, ok := p.e47(); ok {
+		return out, true
+	}
+	if out, ok := p.e48(); ok {
+		return out, true
+	}
+	if out, ok := p.e145(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// [\t-\n\014-\r ]* (FUNCTION_DEFINITION / FUNCTION_CALL / ControlStatem...
+func (p *parser) e147() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e146()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// ([\t-\n\014-\r ]* (FUNCTION_DEFINITION / FUNCTION_CALL / ControlState...
+func (p *parser) e148() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e147()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// PROGRAM <- ([\t-\n\014-\r ]* (FUNCTION_DEFINITION / FUNCTION_CALL / C...
+func (p *parser) e149() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e148()
+	if !ok {
+		return nil, false
+	}
+	return p.node(34, position, line, out), true
+}
+
+// "{"
+func (p *parser) e150() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("{") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (FUNCTION_DEFINITION / FUNCTION_CA...
+func (p *parser) e151() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e148()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// "}"
+func (p *parser) e152() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("}") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "{" [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (FUNCTION_DEFINITION / FUNCTIO...
+func (p *parser) e153() ([]*ParseTree, bool) {
+	if _, ok := p.e150(); !ok {
+		return nil, false
+	}
+	out, ok := p.e151()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e152(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// [\t-\n\014-\r ]* "{" [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (FUNCTION_DEF...
+func (p *parser) e154() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e153()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// BLOCK <- [\t-\n\014-\r ]* "{" [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (FUN...
+func (p *parser) e155() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e154()
+	if !ok {
+		return nil, false
+	}
+	return p.node(16, position, line, out), true
+}
+
+// "break"
+func (p *parser) e156() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("break") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "break"
+func (p *parser) e157() ([]*ParseTree, bool) {
+	_, ok := p.e156()
+	return nil, ok
+}
+
+// BREAK <- "break"
+func (p *parser) e158() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e157()
+	if !ok {
+		return nil, false
+	}
+	return p.node(27, position, line, out), true
+}
+
+// BREAK
+func (p *parser) e159() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e158()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "continue"
+func (p *parser) e160() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("continue") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "continue"
+func (p *parser) e161() ([]*ParseTree, bool) {
+	_, ok := p.e160()
+	return nil, ok
+}
+
+// CONTINUE <- "continue"
+func (p *parser) e162() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e161()
+	if !ok {
+		return nil, false
+	}
+	return p.node(28, position, line, out), true
+}
+
+// CONTINUE
+func (p *parser) e163() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e162()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "return"
+func (p *parser) e164() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("return") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "return"
+func (p *parser) e165() ([]*ParseTree, bool) {
+	_, ok := p.e164()
+	return nil, ok
+}
+
+// "return" [\t-\n\014-\r ]* EXPRESSION
+func (p *parser) e166() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e165()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e141()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// RETURN <- "return" [\t-\n\014-\r ]* EXPRESSION
+func (p *parser) e167() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e166()
+	if !ok {
+		return nil, false
+	}
+	return p.node(29, position, line, out), true
+}
+
+// RETURN
+func (p *parser) e168() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e167()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "for"
+func (p *parser) e169() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("for") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "for"
+func (p *parser) e170() ([]*ParseTree, bool) {
+	_, ok := p.e169()
+	return nil, ok
+}
+
+// "in"
+func (p *parser) e171() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("in") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "in"
+func (p *parser) e172() ([]*ParseTree, bool) {
+	_, ok := p.e171()
+	return nil, ok
+}
+
+// "for" [\t-\n\014-\r ]* IDENTIFIER [\t-\n\014-\r ]* "in" [\t-\n\014-\r...
+func (p *parser) e173() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e170()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e13()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e172()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e13()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e155()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// FOREACH <- "for" [\t-\n\014-\r ]* IDENTIFIER [\t-\n\014-\r ]* "in" [\...
+func (p *parser) e174() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e173()
+	if !ok {
+		return nil, false
+	}
+	return p.node(11, position, line, out), true
+}
+
+// FOREACH
+func (p *parser) e175() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e174()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// ASSIGNMENT [\t-\n\014-\r ]* "," AssignmentList1
+func (p *parser) e176() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e144()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e18()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e179()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// ASSIGNMENT [\t-\n\014-\r ]* "," AssignmentList1
+func (p *parser) e177() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e176()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// ASSIGNMENT [\t-\n\014-\r ]* "," AssignmentList1 / ASSIGNMENT
+func (p *parser) e178() ([]*ParseTree, bool) {
+	if out, ok := p.e177(); ok {
+		return out, true
+	}
+	if out, ok := p.e145(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// [\t-\n\014-\r ]* (ASSIGNMENT [\t-\n\014-\r ]* "," AssignmentList1 / A...
+func (p *parser) e179() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e178()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// [\t-\n\014-\r ]* (ASSIGNMENT [\t-\n\014-\r ]* "," AssignmentList1 / A...
+func (p *parser) e180() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e179()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// [\t-\n\014-\r ]* (ASSIGNMENT [\t-\n\014-\r ]* "," AssignmentList1 / A...
+func (p *parser) e181() ([]*ParseTree, bool) {
+	if out, ok := p.e180(); ok {
+		return out, true
+	}
+	if out, ok := p.e26(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (ASSIGNMENT [\t-\n\014-\r ]* "," A...
+func (p *parser) e182() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e181()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// FOR_INIT <- [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (ASSIGNMENT [\t-\n\014...
+func (p *parser) e183() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e182()
+	if !ok {
+		return nil, false
+	}
+	return p.node(13, position, line, out), true
+}
+
+// ";"
+func (p *parser) e184() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal(";") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// ";"
+func (p *parser) e185() ([]*ParseTree, bool) {
+	_, ok := p.e184()
+	return nil, ok
+}
+
+// FOR_CONDITION <- EXPRESSION
+func (p *parser) e186() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e141()
+	if !ok {
+		return nil, false
+	}
+	return p.node(14, position, line, out), true
+}
+
+// FOR_STEP <- [\t-\n\014-\r ]* ([\t-\n\014-\r ]* (ASSIGNMENT [\t-\n\014...
+func (p *parser) e187() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e182()
+	if !ok {
+		return nil, false
+	}
+	return p.node(15, position, line, out), true
+}
+
+// "for" [\t-\n\014-\r ]* FOR_INIT [\t-\n\014-\r ]* ";" [\t-\n\014-\r ]*...
+func (p *parser) e188() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e170()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e183()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e185()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e186()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e185()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e187()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e155()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// FOR <- "for" [\t-\n\014-\r ]* FOR_INIT [\t-\n\014-\r ]* ";" [\t-\n\01...
+func (p *parser) e189() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e188()
+	if !ok {
+		return nil, false
+	}
+	return p.node(12, position, line, out), true
+}
+
+// FOR
+func (p *parser) e190() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e189()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// FOREACH / FOR
+func (p *parser) e191() ([]*ParseTree, bool) {
+	if out, ok := p.e175(); ok {
+		return out, true
+	}
+	if out, ok := p.e190(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// FOREACH / FOR
+func (p *parser) e192() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e191()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "if"
+func (p *parser) e193() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("if") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "if"
+func (p *parser) e194() ([]*ParseTree, bool) {
+	_, ok := p.e193()
+	return nil, ok
+}
+
+// "else"
+func (p *parser) e195() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("else") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "else"
+func (p *parser) e196() ([]*ParseTree, bool) {
+	_, ok := p.e195()
+	return nil, ok
+}
+
+// "if" [\t-\n\014-\r ]* EXPRESSION [\t-\n\014-\r ]* BLOCK [\t-\n\014-\r...
+func (p *parser) e197() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e194()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e141()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e155()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e196()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e155()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// IFTHENELSE <- "if" [\t-\n\014-\r ]* EXPRESSION [\t-\n\014-\r ]* BLOCK...
+func (p *parser) e198() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e197()
+	if !ok {
+		return nil, false
+	}
+	return p.node(18, position, line, out), true
+}
+
+// IFTHENELSE
+func (p *parser) e199() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e198()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "if" [\t-\n\014-\r ]* EXPRESSION [\t-\n\014-\r ]* BLOCK
+func (p *parser) e200() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e194()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e141()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e155()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// IFTHEN <- "if" [\t-\n\014-\r ]* EXPRESSION [\t-\n\014-\r ]* BLOCK
+func (p *parser) e201() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e200()
+	if !ok {
+		return nil, false
+	}
+	return p.node(17, position, line, out), true
+}
+
+// IFTHEN
+func (p *parser) e202() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e201()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// IFTHENELSE / IFTHEN
+func (p *parser) e203() ([]*ParseTree, bool) {
+	if out, ok := p.e199(); ok {
+		return out, true
+	}
+	if out, ok := p.e202(); ok {
+		return out, true
+	}
+	return nil, false
+}
+
+// IFTHENELSE / IFTHEN
+func (p *parser) e204() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e203()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// "switch"
+func (p *parser) e205() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("switch") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "switch"
+func (p *parser) e206() ([]*ParseTree, bool) {
+	_, ok := p.e205()
+	return nil, ok
+}
+
+// "case"
+func (p *parser) e207() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal("case") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// "case"
+func (p *parser) e208() ([]*ParseTree, bool) {
+	_, ok := p.e207()
+	return nil, ok
+}
+
+// ":"
+func (p *parser) e209() ([]*ParseTree, bool) {
+	start := p.position
+	if !p.literal(":") {
+		return nil, false
+	}
+	return p.leaf(start), true
+}
+
+// ":"
+func (p *parser) e210() ([]*ParseTree, bool) {
+	_, ok := p.e209()
+	return nil, ok
+}
+
+// "case" [\t-\n\014-\r ]* Expression [\t-\n\014-\r ]* ":" [\t-\n\014-\r...
+func (p *parser) e211() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e208()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e141()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e210()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e155()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// [\t-\n\014-\r ]* "case" [\t-\n\014-\r ]* Expression [\t-\n\014-\r ]* ...
+func (p *parser) e212() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e211()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// CASE <- [\t-\n\014-\r ]* "case" [\t-\n\014-\r ]* Expression [\t-\n\01...
+func (p *parser) e213() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e212()
+	if !ok {
+		return nil, false
+	}
+	return p.node(20, position, line, out), true
+}
+
+// CASE*
+func (p *parser) e214() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	for {
+		position, line := p.position, p.line
+		out, ok := p.e213()
+		if !ok {
+			p.position, p.line = position, line
+			break
+		}
+		nodes = concat(nodes, out)
+		if p.position == position {
+			break
+		}
+	}
+	return nodes, true
+}
+
+// "else" [\t-\n\014-\r ]* ":" [\t-\n\014-\r ]* Block
+func (p *parser) e215() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e196()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e210()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e155()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// [\t-\n\014-\r ]* "else" [\t-\n\014-\r ]* ":" [\t-\n\014-\r ]* Block [...
+func (p *parser) e216() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e215()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// CASE_ELSE <- [\t-\n\014-\r ]* "else" [\t-\n\014-\r ]* ":" [\t-\n\014-...
+func (p *parser) e217() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e216()
+	if !ok {
+		return nil, false
+	}
+	return p.node(21, position, line, out), true
+}
+
+// CASE_ELSE
+func (p *parser) e218() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e217()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// CASE* CASE_ELSE
+func (p *parser) e219() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e214()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e218()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// [\t-\n\014-\r ]* CASE* CASE_ELSE [\t-\n\014-\r ]*
+func (p *parser) e220() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e219()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// "{" [\t-\n\014-\r ]* CASE* CASE_ELSE [\t-\n\014-\r ]* "}"
+func (p *parser) e221() ([]*ParseTree, bool) {
+	if _, ok := p.e150(); !ok {
+		return nil, false
+	}
+	out, ok := p.e220()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e152(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// [\t-\n\014-\r ]* "{" [\t-\n\014-\r ]* CASE* CASE_ELSE [\t-\n\014-\r ]...
+func (p *parser) e222() ([]*ParseTree, bool) {
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	out, ok := p.e221()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := p.e3(); !ok {
+		return nil, false
+	}
+	return out, true
+}
+
+// "switch" [\t-\n\014-\r ]* Expression [\t-\n\014-\r ]* [\t-\n\014-\r ]...
+func (p *parser) e223() ([]*ParseTree, bool) {
+	var nodes []*ParseTree
+	out, ok := p.e206()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e141()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e3()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	out, ok = p.e222()
+	if !ok {
+		return nil, false
+	}
+	nodes = concat(nodes, out)
+	return nodes, true
+}
+
+// SWITCH <- "switch" [\t-\n\014-\r ]* Expression [\t-\n\014-\r ]* [\t-\...
+func (p *parser) e224() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e223()
+	if !ok {
+		return nil, false
+	}
+	return p.node(19, position, line, out), true
+}
+
+// SWITCH
+func (p *parser) e225() ([]*ParseTree, bool) {
+	position, line := p.position, p.line
+	out, ok := p.e224()
+	if !ok {
+		p.position, p.line = position, line
+	}
+	return out, ok
+}
+
+// BREAK / CONTINUE / RETURN / FOREACH / FOR / IFTHENELSE / IFTHEN / SWITCH
+func (p *parser) e226() ([]*ParseTree, bool) {
+	if out, ok := p.e159(); ok {
+		return out, true
+	}
+	if out, ok := p.e163(); ok {
+		return out, true
+	}
+	if out, ok := p.e168(); ok {
+		return out, true
+	}
+	if out, ok := p.e192(); ok {
+		return out, true
+	}
+	if out, ok := p.e204(); ok {
+		return out, true
+	}
+	if out, ok := p.e225(); ok {
+		return out, true
+	}
+	return nil, false
+}
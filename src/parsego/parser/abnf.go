@@ -0,0 +1,472 @@
+package pg
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+	The core rules of RFC 5234 appendix B.1, defined in grammars using them
+	unless the grammar defines them itself
+*/
+var abnfCoreRules = map[string]func() Parser{
+	"ALPHA":  func() Parser { return CharClass(false, CharRange{'A', 'Z'}, CharRange{'a', 'z'}) },
+	"BIT":    func() Parser { return CharClass(false, CharRange{'0', '1'}) },
+	"CHAR":   func() Parser { return CharClass(false, CharRange{0x01, 0x7f}) },
+	"CR":     func() Parser { return Character('\r') },
+	"CRLF":   func() Parser { return String("\r\n") },
+	"CTL":    func() Parser { return CharClass(false, CharRange{0x00, 0x1f}, CharRange{0x7f, 0x7f}) },
+	"DIGIT":  func() Parser { return CharClass(false, CharRange{'0', '9'}) },
+	"DQUOTE": func() Parser { return Character('"') },
+	"HEXDIG": func() Parser { return CharClass(false, CharRange{'0', '9'}, CharRange{'A', 'F'}, CharRange{'a', 'f'}) },
+	"HTAB":   func() Parser { return Character('\t') },
+	"LF":     func() Parser { return Character('\n') },
+	"LWSP": func() Parser {
+		wsp := CharClass(false, CharRange{' ', ' '}, CharRange{'\t', '\t'})
+		return Many(TryAny(wsp, Concat(String("\r\n"), wsp)))
+	},
+	"OCTET": func() Parser { return CharClass(false, CharRange{0x00, 0xff}) },
+	"SP":    func() Parser { return Character(' ') },
+	"VCHAR": func() Parser { return CharClass(false, CharRange{0x21, 0x7e}) },
+	"WSP":   func() Parser { return CharClass(false, CharRange{' ', ' '}, CharRange{'\t', '\t'}) },
+}
+
+/*
+	Loads a grammar written in ABNF (RFC 5234 and the %s and %i
+	strings of RFC 7405), as found in RFCs:
+
+	; comments run to the end of the line
+	date        = year "-" month "-" day
+	year        = 4DIGIT
+	month       = %x30 %x31-39 / %x31 %x30-32
+	day         = 2DIGIT / "?"
+
+	with concatenation, alternatives / and =/, groups, [optional]
+	elements, the *, n*m and n repetitions, case insensitive "strings",
+	and %b, %d and %x values, ranges and strings like %x0D.0A.
+	Rule names are case insensitive and every rule builds nodes of its own
+	type, numbered in order of definition in Types, except the core rules
+	(ALPHA, DIGIT, CRLF...) which pass on what they match.
+	Rules continue on the lines indented further than them, the grammar may
+	be indented as a whole and the first rule is the start rule.
+
+	Alternatives are tried in order, like PEG choices, so an alternative
+	matching a prefix of a later one must come after it: Check reports the
+	ones that cannot. Values above 255 and <prose> are not supported.
+*/
+func LoadABNF(src string) (*Grammar, error) {
+	grammar := newGrammar("abnf")
+	if err := loadABNF(grammar, src); err != nil {
+		return nil, err
+	}
+	return grammar, nil
+}
+
+func loadABNF(grammar *Grammar, src string) error {
+	loader := &abnfLoader{
+		textLoader:   newTextLoader("abnf", src, grammar),
+		alternatives: make(map[string][]Parser),
+	}
+	if err := loader.parseGrammar(); err != nil {
+		return err
+	}
+	for _, name := range loader.names {
+		loader.define(name, choice(loader.alternatives[grammar.key(name)]), ruleNode)
+	}
+	core := []string{}
+	for name := range abnfCoreRules {
+		if _, ok := loader.references[grammar.key(name)]; ok && !grammar.defined(name) {
+			core = append(core, name)
+		}
+	}
+	sort.Strings(core)
+	for _, name := range core {
+		loader.define(name, abnfCoreRules[name](), ruleInline)
+	}
+	return loader.checkReferences()
+}
+
+/*
+	Loads an ABNF grammar, panicking on errors
+*/
+func MustLoadABNF(src string) *Grammar {
+	grammar, err := LoadABNF(src)
+	if err != nil {
+		panic(err)
+	}
+	return grammar
+}
+
+/*
+	ABNF parsing
+*/
+
+type abnfLoader struct {
+	textLoader
+	names        []string
+	alternatives map[string][]Parser
+	indent       int
+}
+
+func (self *abnfLoader) column() int {
+	return self.position - strings.LastIndexByte(self.source[:self.position], '\n') - 1
+}
+
+/*
+	Skips blanks, comments and empty lines between rules
+*/
+func (self *abnfLoader) skipBlank() {
+	for self.position < len(self.source) {
+		switch self.source[self.position] {
+		case ' ', '\t', '\r', '\n':
+			self.position += 1
+		case ';':
+			self.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (self *abnfLoader) skipComment() {
+	for self.position < len(self.source) && self.source[self.position] != '\n' && self.source[self.position] != '\r' {
+		self.position += 1
+	}
+}
+
+/*
+	Skips blanks and comments inside a rule, stopping at the end of the
+	line unless the next line is indented further than the rules,
+	continuing the rule
+*/
+func (self *abnfLoader) skipSpacing() {
+	for self.position < len(self.source) {
+		switch self.source[self.position] {
+		case ' ', '\t':
+			self.position += 1
+		case ';':
+			self.skipComment()
+		case '\r', '\n':
+			next := strings.IndexByte(self.source[self.position:], '\n')
+			if next < 0 {
+				return
+			}
+			next += self.position + 1
+			start := next
+			for next < len(self.source) && (self.source[next] == ' ' || self.source[next] == '\t') {
+				next += 1
+			}
+			if next < len(self.source) && next-start <= self.indent &&
+				self.source[next] != '\r' && self.source[next] != '\n' && self.source[next] != ';' {
+				return
+			}
+			self.position = next
+		default:
+			return
+		}
+	}
+}
+
+func (self *abnfLoader) atLineEnd() bool {
+	c := self.peek()
+	return self.position >= len(self.source) || c == '\r' || c == '\n'
+}
+
+func isAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (self *abnfLoader) parseRulename() string {
+	start := self.position
+	if !isAlpha(self.peek()) {
+		return ""
+	}
+	for c := self.peek(); isAlpha(c) || isDigit(c) || c == '-'; c = self.peek() {
+		self.position += 1
+	}
+	return self.source[start:self.position]
+}
+
+func (self *abnfLoader) parseGrammar() error {
+	self.skipBlank()
+	self.indent = self.column()
+	for self.position < len(self.source) {
+		if err := self.parseRule(); err != nil {
+			return err
+		}
+		self.skipBlank()
+	}
+	return nil
+}
+
+func (self *abnfLoader) parseRule() error {
+	start := self.position
+	name := self.parseRulename()
+	if name == "" {
+		return self.unexpected()
+	}
+	self.skipSpacing()
+	if self.peek() != '=' {
+		return self.errorf("expected = after %s", name)
+	}
+	self.position += 1
+	incremental := self.peek() == '/'
+	if incremental {
+		self.position += 1
+	}
+	self.skipSpacing()
+
+	key := self.grammar.key(name)
+	_, defined := self.alternatives[key]
+	switch {
+	case incremental && !defined:
+		self.position = start
+		return self.errorf("rule %s extended before its definition", name)
+	case !incremental && defined:
+		self.position = start
+		return self.errorf("rule %s redefined", name)
+	case !incremental:
+		self.names = append(self.names, name)
+	}
+	alternatives, err := self.parseAlternatives()
+	if err != nil {
+		return err
+	}
+	if !self.atLineEnd() {
+		return self.unexpected()
+	}
+	self.alternatives[key] = append(self.alternatives[key], alternatives...)
+	return nil
+}
+
+func (self *abnfLoader) parseAlternatives() ([]Parser, error) {
+	alternatives := []Parser{}
+	for {
+		concatenation, err := self.parseConcatenation()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, concatenation)
+		if self.peek() != '/' {
+			return alternatives, nil
+		}
+		self.position += 1
+		self.skipSpacing()
+	}
+}
+
+func (self *abnfLoader) parseConcatenation() (Parser, error) {
+	items := []Parser{}
+	for !self.atLineEnd() {
+		switch self.peek() {
+		case '/', ')', ']':
+		default:
+			item, err := self.parseRepetition()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+		break
+	}
+	if len(items) == 0 {
+		return nil, self.unexpected()
+	}
+	return sequence(items), nil
+}
+
+func (self *abnfLoader) parseRepetition() (Parser, error) {
+	min, max := 1, 1
+	if isDigit(self.peek()) || self.peek() == '*' {
+		min, max = self.parseCount(), -1
+		if self.peek() == '*' {
+			self.position += 1
+			if isDigit(self.peek()) {
+				max = self.parseCount()
+			}
+		} else {
+			max = min
+		}
+		if max >= 0 && max < min {
+			return nil, self.errorf("invalid repetition %d*%d", min, max)
+		}
+	}
+	element, err := self.parseElement()
+	if err != nil {
+		return nil, err
+	}
+	self.skipSpacing()
+	if min == 1 && max == 1 {
+		return element, nil
+	}
+	return repeat(element, min, max), nil
+}
+
+func (self *abnfLoader) parseCount() int {
+	start := self.position
+	for isDigit(self.peek()) {
+		self.position += 1
+	}
+	count, _ := strconv.Atoi(self.source[start:self.position])
+	return count
+}
+
+func (self *abnfLoader) parseElement() (Parser, error) {
+	switch c := self.peek(); {
+	case isAlpha(c):
+		start := self.position
+		return self.reference(self.parseRulename(), start), nil
+	case c == '(' || c == '[':
+		self.position += 1
+		self.skipSpacing()
+		alternatives, err := self.parseAlternatives()
+		if err != nil {
+			return nil, err
+		}
+		closing := byte(')')
+		if c == '[' {
+			closing = ']'
+		}
+		if self.peek() != closing {
+			return nil, self.unexpected()
+		}
+		self.position += 1
+		if c == '[' {
+			return Optional(choice(alternatives)), nil
+		}
+		return choice(alternatives), nil
+	case c == '"':
+		return self.parseString(true)
+	case c == '%':
+		return self.parseValue()
+	case c == '<':
+		return nil, self.errorf("prose values are not supported")
+	}
+	return nil, self.unexpected()
+}
+
+/*
+	A quoted string, with no escapes
+*/
+func (self *abnfLoader) parseString(ignoreCase bool) (Parser, error) {
+	self.position += 1
+	end := strings.IndexByte(self.source[self.position:], '"')
+	if end < 0 || strings.ContainsAny(self.source[self.position:self.position+end], "\r\n") {
+		return nil, self.errorf("unterminated string")
+	}
+	text := self.source[self.position : self.position+end]
+	self.position += end + 1
+	if !ignoreCase {
+		if text == "" {
+			return Empty(), nil
+		}
+		return String(text), nil
+	}
+	return caseless(text), nil
+}
+
+/*
+	Matches text in any case, letters by class and the rest by string
+*/
+func caseless(text string) Parser {
+	items := []Parser{}
+	literal := 0
+	for i := 0; i < len(text); i += 1 {
+		if c := text[i]; isAlpha(c) {
+			if literal < i {
+				items = append(items, String(text[literal:i]))
+			}
+			lower, upper := c|0x20, c&^0x20
+			items = append(items, CharClass(false, CharRange{upper, upper}, CharRange{lower, lower}))
+			literal = i + 1
+		}
+	}
+	if literal < len(text) {
+		items = append(items, String(text[literal:]))
+	}
+	return sequence(items)
+}
+
+/*
+	%s"case sensitive", %i"case insensitive", %x30-39, %d13.10, %b101...
+*/
+func (self *abnfLoader) parseValue() (Parser, error) {
+	start := self.position
+	self.position += 1
+	base := 0
+	switch self.peek() {
+	case 's', 'i':
+		ignoreCase := self.peek() == 'i'
+		self.position += 1
+		if self.peek() != '"' {
+			return nil, self.unexpected()
+		}
+		return self.parseString(ignoreCase)
+	case 'b', 'B':
+		base = 2
+	case 'd', 'D':
+		base = 10
+	case 'x', 'X':
+		base = 16
+	default:
+		return nil, self.unexpected()
+	}
+	self.position += 1
+	low, err := self.parseNumber(base)
+	if err != nil {
+		return nil, err
+	}
+	switch self.peek() {
+	case '-':
+		self.position += 1
+		high, err := self.parseNumber(base)
+		if err != nil {
+			return nil, err
+		}
+		if high < low {
+			text := self.source[start:self.position]
+			self.position = start
+			return nil, self.errorf("invalid range %s", text)
+		}
+		return CharClass(false, CharRange{low, high}), nil
+	case '.':
+		text := []byte{low}
+		for self.peek() == '.' {
+			self.position += 1
+			c, err := self.parseNumber(base)
+			if err != nil {
+				return nil, err
+			}
+			text = append(text, c)
+		}
+		return String(string(text)), nil
+	}
+	return Character(int(low)), nil
+}
+
+func (self *abnfLoader) parseNumber(base int) (byte, error) {
+	start := self.position
+	for c := self.peek(); isDigit(c) || base == 16 && isAlpha(c); c = self.peek() {
+		self.position += 1
+	}
+	if start == self.position {
+		return 0, self.unexpected()
+	}
+	text := self.source[start:self.position]
+	value, err := strconv.ParseUint(text, base, 64)
+	if err != nil {
+		self.position = start
+		return 0, self.errorf("invalid value %s", text)
+	}
+	if value > 0xff {
+		self.position = start
+		return 0, self.errorf("value %s above 255 is not supported", text)
+	}
+	return byte(value), nil
+}
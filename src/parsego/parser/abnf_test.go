@@ -0,0 +1,116 @@
+package pg_test
+
+import (
+	"parsego/parser"
+	"strings"
+	"testing"
+)
+
+const dateGrammar = `
+	; comments run to the end of the line
+	date        = year "-" month "-" day
+	year        = 4DIGIT
+	month       = %x30 %x31-39 / %x31 %x30-32
+	day         = 2DIGIT / "?"
+`
+
+func TestLoadABNF(t *testing.T) {
+	grammar := pg.MustLoadABNF(dateGrammar)
+	if names := strings.Join(grammar.Names, " "); names != "date year month day DIGIT" {
+		t.Errorf("expected the rules in order of definition then the core rules used, got %s", names)
+	}
+	if len(grammar.Types) != 5 || grammar.Types[1] != "date" || grammar.Types[4] != "day" {
+		t.Errorf("expected types for the rules defined, got %v", grammar.Types)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2024-07-15", `(1 (2 "2024") "-" (3 "07") "-" (4 "15"))`},
+		{"2024-12-?", `(1 (2 "2024") "-" (3 "12") "-" (4 "?"))`},
+	}
+	for _, test := range tests {
+		out, err := grammar.Parse(test.input)
+		if err != nil {
+			t.Errorf("%s: %s", test.input, err)
+		} else if actual := sexprs(out); actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.input, test.expected, actual)
+		}
+	}
+	for _, input := range []string{"2024-13-01", "24-01-01", "2024-00-01"} {
+		if _, err := grammar.Parse(input); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
+
+func TestLoadABNFExpressions(t *testing.T) {
+	tests := []struct {
+		name     string
+		grammar  string
+		input    string
+		expected string
+	}{
+		{"case insensitive string", `s = "aB1"`, "Ab1", `(1 "Ab1")`},
+		{"case sensitive string", `s = %s"aB" / %i"cd"`, "CD", `(1 "CD")`},
+		{"empty string", `s = "" "a"`, "a", `(1 "a")`},
+		{"decimal string", `s = %d13.10`, "\r\n", `(1 "\r\n")`},
+		{"binary value", `s = %b1000001`, "A", `(1 "A")`},
+		{"range", `s = 1*%x61-63`, "cab", `(1 "cab")`},
+		{"exact repetition", `s = 3"a"`, "aaa", `(1 "aaa")`},
+		{"bounded repetition", `s = 1*2"a" "b"`, "aab", `(1 "aab")`},
+		{"at most", `s = *1"a" "b"`, "b", `(1 "b")`},
+		{"optional", `s = ["a"] "b"`, "ab", `(1 "ab")`},
+		{"group", `s = ("a" / "b") "c"`, "bc", `(1 "bc")`},
+		{"incremental", "s = \"a\"\ns =/ \"b\"", "b", `(1 "b")`},
+		{"continued lines", "s = \"a\"\n    \"b\" ; comment\n    / \"c\"\nt = \"d\"", "ab", `(1 "ab")`},
+		{"case insensitive names", "S = Item\nitem = \"a\"", "a", `(1 (2 "a"))`},
+		{"core rules", `s = ALPHA DIGIT HEXDIG`, "x7F", `(1 "x7F")`},
+		{"core rule defined", "s = 2DIGIT\nDIGIT = \"1\"", "11", `(1 (2 "1") (2 "1"))`},
+		{"crlf", "s = \"a\"\r\nt = \"b\"\r\n", "A", `(1 "A")`},
+	}
+	for _, test := range tests {
+		grammar, err := pg.LoadABNF(test.grammar)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		out, err := grammar.Parse(test.input)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		} else if actual := sexprs(out); actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestLoadABNFErrors(t *testing.T) {
+	tests := []struct {
+		grammar  string
+		expected string
+	}{
+		{"", "pg: abnf 1:1: no rules"},
+		{"s = t", "pg: abnf 1:5: undefined rule t"},
+		{"s \"a\"", "pg: abnf 1:3: expected = after s"},
+		{"s = \"a\"\nS = \"b\"", "pg: abnf 2:1: rule S redefined"},
+		{"s =/ \"a\"", "pg: abnf 1:1: rule s extended before its definition"},
+		{"s =", "pg: abnf 1:4: unexpected end of grammar"},
+		{"s = (\"a\"", "pg: abnf 1:9: unexpected end of grammar"},
+		{"s = \"a", "pg: abnf 1:6: unterminated string"},
+		{"s = 3*2\"a\"", "pg: abnf 1:8: invalid repetition 3*2"},
+		{"s = %x39-30", "pg: abnf 1:5: invalid range %x39-30"},
+		{"s = %x100", "pg: abnf 1:7: value 100 above 255 is not supported"},
+		{"s = %b2", "pg: abnf 1:7: invalid value 2"},
+		{"s = %q1", "pg: abnf 1:6: unexpected 'q'"},
+		{"s = <prose>", "pg: abnf 1:5: prose values are not supported"},
+	}
+	for _, test := range tests {
+		grammar, err := pg.LoadABNF(test.grammar)
+		if err == nil {
+			t.Errorf("%q: expected %s, got rules %v", test.grammar, test.expected, grammar.Names)
+		} else if err.Error() != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.grammar, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package pg
+
+import (
+	"parsego/parsetree"
+	"sync"
+)
+
+/*
+	The number of trees, or of list items, allocated at once by an Arena
+*/
+const ARENA_BLOCK = 1024
+
+/*
+	Allocates the trees of parses in blocks rather than one by one.
+	Set on a ParseState with SetArena, it provides the leaves and nodes
+	built by the parsers, the lists holding them and the children of nodes.
+
+	Parsers restoring the state after a failure, like Try, Optional,
+	the iterations of Many and lookaheads, give back the trees allocated
+	meanwhile, which nothing can refer to anymore.
+	The other trees stay valid until Release, which recycles them all at once:
+	callers release the arena when done with the trees of the parse,
+	and must not keep any of them, or their children, past that point.
+	Arenas are not safe for concurrent use, one per parse at a time.
+*/
+type Arena struct {
+	trees     [][]arenaTree
+	treeBlock int
+	lists     [][]*pt.ParseTree
+	listBlock int
+}
+
+/*
+	The trees and lists in use, as the current block and its length
+*/
+type arenaTop struct {
+	treeBlock, trees int
+	listBlock, lists int
+}
+
+/*
+	A tree and the list holding it, allocated together
+*/
+type arenaTree struct {
+	node pt.ParseTree
+	list [1]*pt.ParseTree
+}
+
+var arenas = sync.Pool{
+	New: func() interface{} {
+		return new(Arena)
+	},
+}
+
+/*
+	An empty arena, reusing the blocks of released ones
+*/
+func NewArena() *Arena {
+	return arenas.Get().(*Arena)
+}
+
+/*
+	Frees every tree of the arena and hands it back for NewArena to reuse
+*/
+func (self *Arena) Release() {
+	self.Reset()
+	arenas.Put(self)
+}
+
+/*
+	Frees every tree of the arena, keeping its blocks for the next parse
+*/
+func (self *Arena) Reset() {
+	self.rewind(arenaTop{})
+}
+
+func (self *Arena) top() arenaTop {
+	if self == nil {
+		return arenaTop{}
+	}
+	top := arenaTop{treeBlock: self.treeBlock, listBlock: self.listBlock}
+	if self.treeBlock < len(self.trees) {
+		top.trees = len(self.trees[self.treeBlock])
+	}
+	if self.listBlock < len(self.lists) {
+		top.lists = len(self.lists[self.listBlock])
+	}
+	return top
+}
+
+/*
+	Frees the trees and lists allocated since top was taken,
+	zeroing them for their next use
+*/
+func (self *Arena) rewind(top arenaTop) {
+	if self == nil {
+		return
+	}
+	for i := top.treeBlock; i <= self.treeBlock && i < len(self.trees); i += 1 {
+		block, used := self.trees[i], 0
+		if i == top.treeBlock {
+			used = top.trees
+		}
+		for j := used; j < len(block); j += 1 {
+			block[j] = arenaTree{}
+		}
+		self.trees[i] = block[:used]
+	}
+	for i := top.listBlock; i <= self.listBlock && i < len(self.lists); i += 1 {
+		block, used := self.lists[i], 0
+		if i == top.listBlock {
+			used = top.lists
+		}
+		for j := used; j < len(block); j += 1 {
+			block[j] = nil
+		}
+		self.lists[i] = block[:used]
+	}
+	self.treeBlock, self.listBlock = top.treeBlock, top.listBlock
+}
+
+/*
+	A new tree and a list of it alone, from the heap for a nil arena
+*/
+func (self *Arena) single() (*pt.ParseTree, []*pt.ParseTree) {
+	var result *arenaTree
+	if self == nil {
+		result = new(arenaTree)
+	} else {
+		for self.treeBlock < len(self.trees) && len(self.trees[self.treeBlock]) == ARENA_BLOCK {
+			self.treeBlock += 1
+		}
+		if self.treeBlock == len(self.trees) {
+			self.trees = append(self.trees, make([]arenaTree, 0, ARENA_BLOCK))
+		}
+		block := self.trees[self.treeBlock]
+		block = block[:len(block)+1]
+		self.trees[self.treeBlock] = block
+		result = &block[len(block)-1]
+	}
+	result.list[0] = &result.node
+	return &result.node, result.list[:]
+}
+
+func (self *Arena) leaf(value []byte) []*pt.ParseTree {
+	node, out := self.single()
+	node.Value = value
+	return out
+}
+
+/*
+	An empty list of capacity size, which ends with it so that
+	appending past it never overwrites the next list of the block
+*/
+func (self *Arena) list(size int) []*pt.ParseTree {
+	if self == nil || size > ARENA_BLOCK {
+		return make([]*pt.ParseTree, 0, size)
+	}
+	for self.listBlock < len(self.lists) && len(self.lists[self.listBlock])+size > ARENA_BLOCK {
+		self.listBlock += 1
+	}
+	if self.listBlock == len(self.lists) {
+		self.lists = append(self.lists, make([]*pt.ParseTree, 0, ARENA_BLOCK))
+	}
+	block := self.lists[self.listBlock]
+	start := len(block)
+	self.lists[self.listBlock] = block[:start+size]
+	return block[start : start : start+size]
+}
+
+/*
+	A copy of trees, nil if empty
+*/
+func (self *Arena) copyList(trees []*pt.ParseTree) []*pt.ParseTree {
+	if len(trees) == 0 {
+		return nil
+	}
+	return append(self.list(len(trees)), trees...)
+}
+
+/*
+	Appends trees to list, growing it in the arena
+*/
+func (self *Arena) appendList(list []*pt.ParseTree, trees []*pt.ParseTree) []*pt.ParseTree {
+	if self != nil && len(list)+len(trees) > cap(list) {
+		list = append(self.list(2*(len(list)+len(trees))), list...)
+	}
+	return append(list, trees...)
+}
+
+/*
+	The arena of in, nil if it has none
+*/
+func arenaOf(in State) *Arena {
+	if state, ok := in.(*ParseState); ok {
+		return state.arena
+	}
+	return nil
+}
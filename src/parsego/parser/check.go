@@ -0,0 +1,399 @@
+package pg
+
+import (
+	"fmt"
+	"parsego/parsetree"
+	"sort"
+	"strings"
+)
+
+const (
+	ISSUE_LEFT_RECURSION = iota
+	ISSUE_NULLABLE_LOOP
+	ISSUE_UNREACHABLE
+	ISSUE_UNDEFINED
+)
+
+/*
+	A grammar mistake found by Check, in the named rule Rule
+*/
+type Issue struct {
+	Kind    int
+	Rule    string
+	Expr    *Expr
+	Message string
+}
+
+func (self *Issue) Error() string {
+	return self.Rule + ": " + self.Message
+}
+
+/*
+	Statically checks the grammar reachable from root for mistakes that
+	would otherwise only show at parse time:
+
+	- left recursion, looping until the stack overflows
+	- Many and Many1 over expressions matching without consuming input,
+	  looping forever
+	- choice alternatives that are never tried, because an earlier one
+	  always succeeds or matches a literal prefix of theirs
+	- Recursive rules with no definition
+
+	Parsers not built from combinators are assumed to consume input.
+*/
+func Check(root *Expr, types pt.NodeTypes) []*Issue {
+	checker := &checker{types: types}
+	checker.analyze(root)
+
+	rules := []*Expr{}
+	if root.Name(types) == "" {
+		rules = append(rules, root)
+	}
+	root.Walk(func(expr *Expr) bool {
+		if expr.Name(types) != "" {
+			rules = append(rules, expr)
+		}
+		return true
+	})
+	for _, rule := range rules {
+		checker.checkRule(rule)
+	}
+	checker.checkLeftRecursion(rules)
+	return checker.issues
+}
+
+type checker struct {
+	types      pt.NodeTypes
+	nullable   map[*Expr]bool
+	infallible map[*Expr]bool
+	issues     []*Issue
+}
+
+/*
+	Rules sharing a name, like the ones built by calling Label
+	more than once, report their issues once
+*/
+func (self *checker) report(kind int, rule *Expr, expr *Expr, format string, args ...interface{}) {
+	issue := &Issue{
+		Kind:    kind,
+		Rule:    self.ruleName(rule),
+		Expr:    expr,
+		Message: fmt.Sprintf(format, args...),
+	}
+	for _, reported := range self.issues {
+		if reported.Kind == issue.Kind && reported.Error() == issue.Error() {
+			return
+		}
+	}
+	self.issues = append(self.issues, issue)
+}
+
+func (self *checker) ruleName(rule *Expr) string {
+	if name := rule.Name(self.types); name != "" {
+		return name
+	}
+	return "Start"
+}
+
+/*
+	Computes which expressions can succeed without consuming input
+	(nullable) and which always succeed (infallible), iterating to a
+	fixpoint. They only differ on the & and ! predicates.
+*/
+func (self *checker) analyze(root *Expr) {
+	exprs := []*Expr{}
+	root.Walk(func(expr *Expr) bool {
+		exprs = append(exprs, expr)
+		return true
+	})
+	self.nullable = make(map[*Expr]bool)
+	self.infallible = make(map[*Expr]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, expr := range exprs {
+			if !self.nullable[expr] && derive(expr, self.nullable, true) {
+				self.nullable[expr] = true
+				changed = true
+			}
+			if !self.infallible[expr] && derive(expr, self.infallible, false) {
+				self.infallible[expr] = true
+				changed = true
+			}
+		}
+	}
+}
+
+func derive(expr *Expr, known map[*Expr]bool, nullable bool) bool {
+	switch expr.Kind {
+	case EXPR_EMPTY, EXPR_STAR, EXPR_OPTIONAL:
+		return true
+	case EXPR_NOT:
+		return nullable
+	case EXPR_AND:
+		return nullable || known[expr.Children[0]]
+	case EXPR_TERMINAL:
+		return expr.Text == ""
+	case EXPR_SEQ, EXPR_BETWEEN:
+		for _, child := range expr.Children {
+			if !known[child] {
+				return false
+			}
+		}
+		return true
+	case EXPR_CHOICE:
+		for _, child := range expr.Children {
+			if known[child] {
+				return true
+			}
+		}
+	case EXPR_PLUS, EXPR_TRY, EXPR_SKIP, EXPR_NODE, EXPR_LABEL:
+		return known[expr.Children[0]]
+	case EXPR_REF:
+		if target := expr.Target(); target != nil {
+			return known[target]
+		}
+	}
+	return false
+}
+
+/*
+	Checks the body of a named rule, or of the unnamed root,
+	stopping at the named rules it uses
+*/
+func (self *checker) checkRule(rule *Expr) {
+	var check func(expr *Expr)
+	check = func(expr *Expr) {
+		switch expr.Kind {
+		case EXPR_STAR, EXPR_PLUS:
+			if self.nullable[expr.Children[0]] {
+				self.report(ISSUE_NULLABLE_LOOP, rule, expr,
+					"%s loops forever, its body can match without consuming input", expr.Format(self.types))
+			}
+		case EXPR_CHOICE:
+			self.checkChoice(rule, expr)
+		}
+		for _, child := range expr.Children {
+			if child.Kind == EXPR_REF && child.Target() == nil {
+				self.report(ISSUE_UNDEFINED, rule, child, "rule %s is not defined", child.Text)
+			}
+			if child.Name(self.types) == "" {
+				check(child)
+			}
+		}
+	}
+	if body := self.body(rule); body != nil && (body == rule || body.Name(self.types) == "") {
+		check(body)
+	}
+}
+
+/*
+	The expression defining a named rule, the root itself if unnamed
+*/
+func (self *checker) body(rule *Expr) *Expr {
+	switch {
+	case rule.Kind == EXPR_REF:
+		return rule.Target()
+	case rule.Name(self.types) != "":
+		return rule.Children[0]
+	}
+	return rule
+}
+
+func (self *checker) checkChoice(rule *Expr, expr *Expr) {
+	for j, later := range expr.Children {
+		for i, earlier := range expr.Children[:j] {
+			reason := ""
+			if self.infallible[earlier] {
+				reason = "always succeeds"
+			} else if literal, ok := self.literal(earlier, nil); ok && literal != "" {
+				if strings.HasPrefix(self.prefix(later, nil), literal) {
+					reason = fmt.Sprintf("matches its prefix %q", literal)
+				}
+			}
+			if reason != "" {
+				self.report(ISSUE_UNREACHABLE, rule, later,
+					"alternative %d %s is unreachable, alternative %d %s %s first",
+					j+1, later.Format(self.types), i+1, earlier.Format(self.types), reason)
+				break
+			}
+		}
+	}
+}
+
+/*
+	The only string matched by expr, if there is one
+*/
+func (self *checker) literal(expr *Expr, visiting map[*Expr]bool) (string, bool) {
+	if visiting[expr] {
+		return "", false
+	}
+	switch expr.Kind {
+	case EXPR_EMPTY:
+		return "", true
+	case EXPR_TERMINAL:
+		return expr.Text, true
+	case EXPR_SEQ, EXPR_BETWEEN:
+		var out strings.Builder
+		for _, child := range expr.Children {
+			literal, ok := self.literal(child, visiting)
+			if !ok {
+				return "", false
+			}
+			out.WriteString(literal)
+		}
+		return out.String(), true
+	case EXPR_CHOICE:
+		if len(expr.Children) == 1 {
+			return self.literal(expr.Children[0], visiting)
+		}
+	case EXPR_TRY, EXPR_SKIP, EXPR_NODE, EXPR_LABEL:
+		return self.literal(expr.Children[0], visiting)
+	case EXPR_REF:
+		if target := expr.Target(); target != nil {
+			return self.literal(target, visit(visiting, expr))
+		}
+	}
+	return "", false
+}
+
+/*
+	A string every match of expr starts with
+*/
+func (self *checker) prefix(expr *Expr, visiting map[*Expr]bool) string {
+	if visiting[expr] {
+		return ""
+	}
+	switch expr.Kind {
+	case EXPR_TERMINAL:
+		return expr.Text
+	case EXPR_SEQ, EXPR_BETWEEN:
+		var out strings.Builder
+		for _, child := range expr.Children {
+			if literal, ok := self.literal(child, visiting); ok {
+				out.WriteString(literal)
+				continue
+			}
+			out.WriteString(self.prefix(child, visiting))
+			break
+		}
+		return out.String()
+	case EXPR_CHOICE:
+		common := ""
+		for i, child := range expr.Children {
+			prefix := self.prefix(child, visiting)
+			if i == 0 {
+				common = prefix
+			}
+			for !strings.HasPrefix(prefix, common) {
+				common = common[:len(common)-1]
+			}
+		}
+		return common
+	case EXPR_PLUS, EXPR_TRY, EXPR_SKIP, EXPR_NODE, EXPR_LABEL:
+		return self.prefix(expr.Children[0], visiting)
+	case EXPR_REF:
+		if target := expr.Target(); target != nil {
+			return self.prefix(target, visit(visiting, expr))
+		}
+	}
+	return ""
+}
+
+func visit(visiting map[*Expr]bool, expr *Expr) map[*Expr]bool {
+	extended := map[*Expr]bool{expr: true}
+	for visited := range visiting {
+		extended[visited] = true
+	}
+	return extended
+}
+
+/*
+	Finds cycles of named rules calling each other
+	before consuming any input
+*/
+func (self *checker) checkLeftRecursion(rules []*Expr) {
+	calls := make(map[*Expr][]*Expr)
+	for _, rule := range rules {
+		if body := self.body(rule); body != nil {
+			calls[rule] = self.leftCalls(body, nil)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[*Expr]int)
+	stack := []*Expr{}
+	reported := make(map[string]bool)
+	var visit func(rule *Expr)
+	visit = func(rule *Expr) {
+		state[rule] = visiting
+		stack = append(stack, rule)
+		for _, callee := range calls[rule] {
+			switch state[callee] {
+			case unvisited:
+				visit(callee)
+			case visiting:
+				self.reportCycle(stack, callee, reported)
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[rule] = done
+	}
+	for _, rule := range rules {
+		if state[rule] == unvisited {
+			visit(rule)
+		}
+	}
+}
+
+func (self *checker) reportCycle(stack []*Expr, start *Expr, reported map[string]bool) {
+	cycle := stack
+	for i, rule := range stack {
+		if rule == start {
+			cycle = stack[i:]
+			break
+		}
+	}
+	names := []string{}
+	for _, rule := range cycle {
+		names = append(names, self.ruleName(rule))
+	}
+	members := append([]string{}, names...)
+	sort.Strings(members)
+	key := strings.Join(members, " ")
+	if reported[key] {
+		return
+	}
+	reported[key] = true
+	self.report(ISSUE_LEFT_RECURSION, start, start,
+		"left recursion %s -> %s", strings.Join(names, " -> "), names[0])
+}
+
+/*
+	The named rules expr may call at the position it starts from
+*/
+func (self *checker) leftCalls(expr *Expr, calls []*Expr) []*Expr {
+	if isAlias(expr) {
+		return append(calls, expr.Target())
+	}
+	if expr.Name(self.types) != "" {
+		return append(calls, expr)
+	}
+	switch expr.Kind {
+	case EXPR_SEQ, EXPR_BETWEEN:
+		for _, child := range expr.Children {
+			calls = self.leftCalls(child, calls)
+			if !self.nullable[child] {
+				break
+			}
+		}
+	case EXPR_CHOICE, EXPR_STAR, EXPR_PLUS, EXPR_OPTIONAL, EXPR_AND, EXPR_NOT, EXPR_TRY, EXPR_SKIP:
+		for _, child := range expr.Children {
+			calls = self.leftCalls(child, calls)
+		}
+	}
+	return calls
+}
@@ -0,0 +1,84 @@
+package pg_test
+
+import (
+	"parsego/parser"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     pg.Parser
+		kind     int
+		expected string
+	}{
+		{"left recursion", pg.Recursive("checkSum", func() pg.Parser {
+			return pg.Any(
+				pg.Try(pg.Concat(pg.Recursive("checkSum", nil), pg.Character('+'), pg.Number())),
+				pg.Number())
+		}), pg.ISSUE_LEFT_RECURSION, "checkSum: left recursion checkSum -> checkSum"},
+		{"mutual left recursion", pg.Recursive("checkA", func() pg.Parser {
+			return pg.Concat(pg.Optional(pg.Character('a')), pg.Recursive("checkB", func() pg.Parser {
+				return pg.Any(pg.Try(pg.Recursive("checkA", nil)), pg.Character('b'))
+			}))
+		}), pg.ISSUE_LEFT_RECURSION, "checkA: left recursion checkA -> checkB -> checkA"},
+		{"nullable loop", pg.Label("spaces", pg.Many(pg.Optional(pg.Character(' ')))),
+			pg.ISSUE_NULLABLE_LOOP, `spaces: (" "?)* loops forever, its body can match without consuming input`},
+		{"always succeeds", pg.Any(pg.Try(pg.Many(pg.Character('a'))), pg.Character('b')),
+			pg.ISSUE_UNREACHABLE, `Start: alternative 2 "b" is unreachable, alternative 1 "a"* always succeeds first`},
+		{"literal prefix", pg.Label("keyword", pg.Any(pg.Try(pg.String("in")), pg.String("int"))),
+			pg.ISSUE_UNREACHABLE, `keyword: alternative 2 "int" is unreachable, alternative 1 "in" matches its prefix "in" first`},
+		{"undefined", pg.Concat(pg.Character('x'), pg.Recursive("checkMissing", func() pg.Parser { return nil })),
+			pg.ISSUE_UNDEFINED, "Start: rule checkMissing is not defined"},
+	}
+	for _, test := range tests {
+		issues := pg.Check(pg.Describe(test.rule), nil)
+		if len(issues) != 1 {
+			t.Errorf("%s: expected one issue, got %v", test.name, issues)
+			continue
+		}
+		if issues[0].Kind != test.kind || issues[0].Error() != test.expected {
+			t.Errorf("%s: expected issue %d %s, got %d %s", test.name, test.kind, test.expected, issues[0].Kind, issues[0])
+		}
+	}
+}
+
+func TestCheckLoadedRules(t *testing.T) {
+	grammar := pg.MustLoadPEG(`
+		Sum    <- Sum "+" Number / Number
+		Number <- [0-9]+
+	`)
+	issues := pg.Check(pg.Describe(grammar.Start()), grammar.Types)
+	if len(issues) != 1 || issues[0].Error() != "Sum: left recursion Sum -> Sum" {
+		t.Errorf("expected Sum to be left recursive, got %v", issues)
+	}
+}
+
+func TestCheckClean(t *testing.T) {
+	rule := pg.Concat(pair(), pg.Many(pg.Concat(pg.Character(';'), pair())))
+	if issues := pg.Check(pg.Describe(rule), nil); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+	ordered := pg.Any(pg.Try(pg.String("int")), pg.Try(pg.String("in")), pg.Many(pg.Char()))
+	if issues := pg.Check(pg.Describe(ordered), nil); len(issues) != 0 {
+		t.Errorf("expected longer keywords first to be fine, got %v", issues)
+	}
+}
+
+/*
+	Rules built by several calls of the same function report once
+*/
+func TestCheckReportsOnce(t *testing.T) {
+	spaces := func() pg.Parser {
+		return pg.Label("blanks", pg.Many(pg.Optional(pg.Whitespace())))
+	}
+	issues := pg.Check(pg.Describe(pg.Concat(spaces(), pg.Character('x'), spaces())), nil)
+	messages := []string{}
+	for _, issue := range issues {
+		messages = append(messages, issue.Error())
+	}
+	if len(issues) != 1 || issues[0].Kind != pg.ISSUE_NULLABLE_LOOP {
+		t.Errorf("expected one nullable loop, got\n%s", strings.Join(messages, "\n"))
+	}
+}
@@ -0,0 +1,346 @@
+package pg
+
+import (
+	"parsego/parsetree"
+)
+
+/*
+	Builds the executable parser for expr, once: the parser is kept in
+	expr.Parser and answers Describe with expr.
+	Cycles must go through EXPR_REF, whose target is compiled on first use.
+*/
+func Compile(expr *Expr) Parser {
+	if expr.Parser != nil {
+		return expr.Parser
+	}
+	switch expr.Kind {
+	case EXPR_EMPTY:
+		expr.Parser = compileEmpty(expr)
+	case EXPR_TERMINAL:
+		expr.Parser = compileTerminal(expr)
+	case EXPR_CLASS:
+		expr.Parser = compileClass(expr)
+	case EXPR_SEQ:
+		expr.Parser = compileSeq(expr, compileAll(expr.Children))
+	case EXPR_CHOICE:
+		expr.Parser = compileChoice(expr, compileAll(expr.Children))
+	case EXPR_STAR, EXPR_PLUS:
+		expr.Parser = compileRepeat(expr, Compile(expr.Children[0]))
+	case EXPR_TRY:
+		expr.Parser = compileTry(expr, Compile(expr.Children[0]))
+	case EXPR_OPTIONAL:
+		expr.Parser = compileOptional(expr, Compile(expr.Children[0]))
+	case EXPR_AND, EXPR_NOT:
+		expr.Parser = compileLookahead(expr, Compile(expr.Children[0]))
+	case EXPR_SKIP:
+		expr.Parser = compileSkip(expr, Compile(expr.Children[0]))
+	case EXPR_BETWEEN:
+		expr.Parser = compileBetween(expr, compileAll(expr.Children))
+	case EXPR_NODE:
+		expr.Parser = traced(expr, "", expr.NodeType, compileNode(expr.NodeType, Compile(expr.Children[0])))
+	case EXPR_REF:
+		expr.Parser = compileRef(expr)
+	case EXPR_LABEL:
+		expr.Parser = traced(expr, expr.Text, TYPE_UNDEFINED, Compile(expr.Children[0]))
+	default:
+		expr.Parser = compileFail(expr)
+	}
+	return expr.Parser
+}
+
+func compileAll(exprs []*Expr) []Parser {
+	matches := []Parser{}
+	for _, expr := range exprs {
+		matches = append(matches, Compile(expr))
+	}
+	return matches
+}
+
+func compileEmpty(expr *Expr) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		return nil, true
+	}
+}
+
+func compileFail(expr *Expr) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		describing(in, expr)
+		return nil, false
+	}
+}
+
+func compileTerminal(expr *Expr) Parser {
+	text := []byte(expr.Text)
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		start := in.GetPosition()
+		for _, c := range text {
+			target, ok := in.Next()
+			if !ok || byte(target) != c {
+				return nil, false
+			}
+		}
+		node, out := arenaOf(in).single()
+		node.Value = slice(in, start, in.GetPosition())
+		if len(node.Value) != len(text) {
+			node.Value = append([]byte{}, text...)
+		}
+		return out, true
+	}
+}
+
+func compileClass(expr *Expr) Parser {
+	var set [256]bool
+	for c := range set {
+		set[c] = expr.Matches(byte(c))
+	}
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		target, ok := in.Next()
+		if ok && set[byte(target)] {
+			node, out := arenaOf(in).single()
+			node.Value = slice(in, in.GetPosition()-1, in.GetPosition())
+			if len(node.Value) != 1 {
+				node.Value = []byte{byte(target)}
+			}
+			return out, true
+		}
+		return nil, false
+	}
+}
+
+func compileSeq(expr *Expr, matches []Parser) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		arena := arenaOf(in)
+		nodes := []*pt.ParseTree{}
+		for _, match := range matches {
+			out, ok := match(in)
+			if !ok {
+				return nil, false
+			}
+			nodes = concat(arena, nodes, out)
+		}
+		return nodes, true
+	}
+}
+
+func compileChoice(expr *Expr, matches []Parser) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		if tracerOf(in) != nil {
+			return tracedAny(choiceId(expr), matches, in)
+		}
+		for _, match := range matches {
+			out, ok := match(in)
+			if ok {
+				return out, true
+			}
+		}
+		return nil, false
+	}
+}
+
+/*
+	Many and Many1: every further iteration is tried,
+	restoring the state when it fails, and the loop stops
+	after an iteration consuming no input
+*/
+func compileRepeat(expr *Expr, match Parser) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		arena := arenaOf(in)
+		nodes := []*pt.ParseTree{}
+		if expr.Kind == EXPR_PLUS {
+			out, ok := match(in)
+			if !ok {
+				return nil, false
+			}
+			nodes = concat(arena, nodes, out)
+		}
+		for {
+			initialPosition := in.GetPosition()
+			initialLineCount := in.GetLineCount()
+			top := arena.top()
+			mark(in)
+			out, ok := match(in)
+			if !ok {
+				in.SetPosition(initialPosition)
+				in.SetLineCount(initialLineCount)
+				arena.rewind(top)
+			}
+			release(in)
+			if !ok {
+				break
+			}
+			nodes = concat(arena, nodes, out)
+			if in.GetPosition() == initialPosition {
+				break
+			}
+		}
+		return nodes, true
+	}
+}
+
+func compileTry(expr *Expr, match Parser) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		initialPosition := in.GetPosition()
+		initialLineCount := in.GetLineCount()
+		arena := arenaOf(in)
+		top := arena.top()
+		mark(in)
+		out, ok := match(in)
+		if !ok {
+			in.SetPosition(initialPosition)
+			in.SetLineCount(initialLineCount)
+			arena.rewind(top)
+		}
+		release(in)
+		return out, ok
+	}
+}
+
+func compileOptional(expr *Expr, match Parser) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		initialPosition := in.GetPosition()
+		initialLineCount := in.GetLineCount()
+		arena := arenaOf(in)
+		top := arena.top()
+		mark(in)
+		out, ok := match(in)
+		if !ok {
+			in.SetPosition(initialPosition)
+			in.SetLineCount(initialLineCount)
+			arena.rewind(top)
+			out = nil
+		}
+		release(in)
+		return out, true
+	}
+}
+
+/*
+	& and !: the state is always restored and nothing is output
+*/
+func compileLookahead(expr *Expr, match Parser) Parser {
+	expected := expr.Kind == EXPR_AND
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		initialPosition := in.GetPosition()
+		initialLineCount := in.GetLineCount()
+		arena := arenaOf(in)
+		top := arena.top()
+		mark(in)
+		_, ok := match(in)
+		in.SetPosition(initialPosition)
+		in.SetLineCount(initialLineCount)
+		arena.rewind(top)
+		release(in)
+		return nil, ok == expected
+	}
+}
+
+func compileSkip(expr *Expr, match Parser) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		_, ok := match(in)
+		return nil, ok
+	}
+}
+
+func compileBetween(expr *Expr, matches []Parser) Parser {
+	left, match, right := matches[0], matches[1], matches[2]
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		_, okl := left(in)
+		if !okl {
+			return nil, false
+		}
+
+		out, ok := match(in)
+		if !ok {
+			return nil, false
+		}
+
+		_, okr := right(in)
+		if !okr {
+			return nil, false
+		}
+
+		return out, true
+	}
+}
+
+func compileNode(nodeType int, match Parser) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		pos := new(pt.InputPosition)
+		pos.StartPosition = in.GetPosition()
+		pos.StartLine = in.GetLineCount()
+		out, ok := match(in)
+		if !ok {
+			return nil, false
+		}
+		pos.EndPosition = in.GetPosition()
+		pos.EndLine = in.GetLineCount()
+
+		arena := arenaOf(in)
+		node, nodes := arena.single()
+		node.Type = nodeType
+		node.Position = *pos
+		if len(out) == 1 && out[0].Type == TYPE_UNDEFINED {
+			node.Value = out[0].Value
+		} else {
+			node.Children = arena.copyList(out)
+		}
+		return nodes, true
+	}
+}
+
+/*
+	References are traced as their rule, unless it is a Specify or Label
+	rule tracing itself, as the rules of loaded grammars are
+*/
+func compileRef(expr *Expr) Parser {
+	match := func(in State) ([]*pt.ParseTree, bool) {
+		target := expr.Target()
+		if target == nil {
+			return nil, false
+		}
+		return Compile(target)(in)
+	}
+	rule := traced(expr, expr.Text, TYPE_UNDEFINED, match)
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		if isAlias(expr) {
+			return match(in)
+		}
+		return rule(in)
+	}
+}
@@ -0,0 +1,159 @@
+package pg_test
+
+import (
+	"fmt"
+	"parsego/parser"
+	"parsego/parsetree"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+/*
+	Trees on one line, untyped leaves as their quoted value:
+	"a" (9041 "1") (9042 "(" (9043 "1") ")")
+*/
+func sexprs(trees []*pt.ParseTree) string {
+	out := []string{}
+	for _, tree := range trees {
+		switch {
+		case tree == nil:
+			out = append(out, "()")
+		case tree.Type == pg.TYPE_UNDEFINED && len(tree.Children) == 0:
+			out = append(out, fmt.Sprintf("%q", tree.Value))
+		case len(tree.Children) == 0:
+			out = append(out, fmt.Sprintf("(%d %q)", tree.Type, tree.Value))
+		default:
+			out = append(out, fmt.Sprintf("(%d %s)", tree.Type, sexprs(tree.Children)))
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+/*
+	Loops over parsers matching without consuming input stop
+	instead of repeating forever
+*/
+func TestRepeatZeroProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     pg.Parser
+		input    string
+		expected string
+	}{
+		{"optional", pg.Concat(pg.Many(pg.Optional(pg.Character('a'))), pg.Character('b')), "aab", "aab"},
+		{"empty", pg.Concat(pg.Many1(pg.Empty()), pg.Character('b')), "b", "b"},
+		{"lookahead", pg.Concat(pg.Many(pg.Not(pg.Character('a'))), pg.Character('b')), "b", "b"},
+		{"nested", pg.Many(pg.Many(pg.Character('a'))), "aaa", "aaa"},
+	}
+	for _, test := range tests {
+		parsers := map[string]func(string) ([]*pt.ParseTree, error){
+			"closures": func(input string) ([]*pt.ParseTree, error) {
+				return pg.Parse(test.rule, input)
+			},
+			"bytecode": func(input string) ([]*pt.ParseTree, error) {
+				return pg.CompileBytecode(pg.Describe(test.rule)).Parse(input)
+			},
+		}
+		for name, parse := range parsers {
+			out, err := parse(test.input)
+			if err != nil {
+				t.Errorf("%s, %s: %s", test.name, name, err)
+			} else if len(out) != 1 || string(out[0].Value) != test.expected {
+				t.Errorf("%s, %s: expected a leaf %s, got %v", test.name, name, test.expected, out)
+			}
+		}
+	}
+}
+
+/*
+	Untyped values only merge with each other, and nodes only take
+	the value of a lone untyped tree, keeping typed trees apart
+*/
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     pg.Parser
+		input    string
+		expected string
+	}{
+		{"values", pg.Concat(pg.Character('a'), pg.Many(pg.Number())), "a12", `"a12"`},
+		{"value then node", pg.Concat(pg.Character('a'), pg.Specify(9041, pg.Number())), "a1", `"a" (9041 "1")`},
+		{"node then value", pg.Concat(pg.Specify(9041, pg.Number()), pg.Character('a')), "1a", `(9041 "1") "a"`},
+		{"node of values", pg.Specify(9042, pg.Concat(pg.Character('x'), pg.Character('y'))), "xy", `(9042 "xy")`},
+		{"node of value and node",
+			pg.Specify(9043, pg.Concat(pg.Character('('), pg.Specify(9041, pg.Number()), pg.Character(')'))),
+			"(1)", `(9043 "(" (9041 "1") ")")`},
+		{"node of a node", pg.Specify(9044, pg.Specify(9041, pg.Number())), "1", `(9044 (9041 "1"))`},
+	}
+	for _, test := range tests {
+		parsers := map[string]func(string) ([]*pt.ParseTree, error){
+			"closures": func(input string) ([]*pt.ParseTree, error) {
+				return pg.Parse(test.rule, input)
+			},
+			"bytecode": func(input string) ([]*pt.ParseTree, error) {
+				return pg.CompileBytecode(pg.Describe(test.rule)).Parse(input)
+			},
+			"reader": func(input string) ([]*pt.ParseTree, error) {
+				return pg.ParseReader(test.rule, strings.NewReader(input))
+			},
+		}
+		for name, parse := range parsers {
+			out, err := parse(test.input)
+			if err != nil {
+				t.Errorf("%s, %s: %s", test.name, name, err)
+			} else if actual := sexprs(out); actual != test.expected {
+				t.Errorf("%s, %s: expected %s, got %s", test.name, name, test.expected, actual)
+			}
+		}
+	}
+}
+
+/*
+	Appending to a value copies it, leaving the input and the values
+	after it as they were
+*/
+func TestValueAppend(t *testing.T) {
+	rule := pg.Concat(pg.Character('a'), pg.Character('b'), pg.Specify(9041, pg.Number()), pg.Specify(9041, pg.Number()))
+	parsers := map[string]func(string) ([]*pt.ParseTree, error){
+		"closures": func(input string) ([]*pt.ParseTree, error) {
+			return pg.Parse(rule, input)
+		},
+		"bytecode": pg.CompileBytecode(pg.Describe(rule)).Parse,
+	}
+	for name, parse := range parsers {
+		out, err := parse("ab12")
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		for _, tree := range out {
+			tree.Value = append(tree.Value, '!')
+		}
+		if actual := sexprs(out); actual != `"ab!" (9041 "1!") (9041 "2!")` {
+			t.Errorf("%s: expected each value to get its !, got %s", name, actual)
+		}
+	}
+}
+
+/*
+	Values merged from leaves apart in the input are built in linear
+	time: four times as many leaves take about four times the memory
+*/
+func TestMergeLinear(t *testing.T) {
+	rule := pg.Many(pg.Concat(pg.Character('a'), pg.Skip(pg.Character(','))))
+	allocated := func(n int) uint64 {
+		var before, after runtime.MemStats
+		input := strings.Repeat("a,", n)
+		runtime.ReadMemStats(&before)
+		out, err := pg.Parse(rule, input)
+		runtime.ReadMemStats(&after)
+		if err != nil || len(out) != 1 || len(out[0].Value) != n {
+			t.Fatalf("expected a value of %d bytes, got %v, %v", n, len(out), err)
+		}
+		return after.TotalAlloc - before.TotalAlloc
+	}
+	small, large := allocated(5000), allocated(20000)
+	if large > 6*small {
+		t.Errorf("expected about 4 times the memory for 4 times the leaves, got %d then %d bytes", small, large)
+	}
+}
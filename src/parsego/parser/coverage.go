@@ -0,0 +1,372 @@
+package pg
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"parsego/parsetree"
+	"sort"
+	"text/tabwriter"
+)
+
+/*
+	Hits for a named rule (Specify, Recursive or Label)
+*/
+type RuleCoverage struct {
+	Name      string
+	Calls     int
+	Successes int
+}
+
+/*
+	Hits for the alternatives of a choice (Any, TryAny).
+	Equal choices are one, wherever they are built, as by every call
+	of a rule function.
+	Rule is the innermost named rule enclosing the choice when first used,
+	Index tells apart the choices of the same rule, in order of first use.
+*/
+type ChoiceCoverage struct {
+	Rule         string
+	Index        int
+	Alternatives []*AlternativeCoverage
+	Failures     int
+}
+
+/*
+	Label is the first named rule entered by the alternative, if any
+*/
+type AlternativeCoverage struct {
+	Label   string
+	Tries   int
+	Matches int
+}
+
+func (self *ChoiceCoverage) Name() string {
+	return fmt.Sprintf("%s#%d", self.Rule, self.Index)
+}
+
+/*
+	A Tracer collecting rule and alternative hits over any number of parses:
+	in.SetTracer(coverage)
+	Node types never specified in the grammar are reported as uncovered rules,
+	choices never reached are only reported when their grammar was added
+	with AddGrammar.
+*/
+type Coverage struct {
+	types    pt.NodeTypes
+	grammars []*Expr
+	rules    map[string]*RuleCoverage
+	choices  map[int]*ChoiceCoverage
+	indexes  map[string]int
+	stack    []coverageFrame
+}
+
+type coverageFrame struct {
+	rule        string
+	choice      *ChoiceCoverage
+	id          int
+	alternative int
+	labeled     bool
+}
+
+func NewCoverage(types pt.NodeTypes) *Coverage {
+	coverage := new(Coverage)
+	coverage.types = types
+	coverage.Reset()
+	return coverage
+}
+
+/*
+	Discards all collected data, keeping the grammars added
+*/
+func (self *Coverage) Reset() {
+	self.rules = make(map[string]*RuleCoverage)
+	self.choices = make(map[int]*ChoiceCoverage)
+	self.indexes = make(map[string]int)
+	self.stack = nil
+	for _, grammar := range self.grammars {
+		self.seed(grammar, "", make(map[*Expr]bool))
+	}
+}
+
+/*
+	Reports every rule and alternative reachable from grammar, as given
+	by Describe, even if no parse reaches it:
+	coverage.AddGrammar(Describe(rule))
+	Choices are then numbered in the order of the grammar rather than
+	of their first use, and alternatives labeled by the rule they start with.
+*/
+func (self *Coverage) AddGrammar(grammar *Expr) {
+	self.grammars = append(self.grammars, grammar)
+	self.seed(grammar, "", make(map[*Expr]bool))
+}
+
+func (self *Coverage) seed(expr *Expr, rule string, seen map[*Expr]bool) {
+	if expr == nil || seen[expr] {
+		return
+	}
+	seen[expr] = true
+	if name, ok := self.ruleName(expr); ok {
+		rule = name
+		self.rule(name)
+	}
+	if expr.Kind == EXPR_CHOICE {
+		id := choiceId(expr)
+		if _, ok := self.choices[id]; !ok {
+			choice := &ChoiceCoverage{Rule: rule, Index: self.indexes[rule]}
+			self.indexes[rule] += 1
+			for _, alternative := range expr.Children {
+				choice.Alternatives = append(choice.Alternatives, &AlternativeCoverage{Label: self.label(alternative)})
+			}
+			self.choices[id] = choice
+		}
+	}
+	for _, child := range expr.Children {
+		self.seed(child, rule, seen)
+	}
+	if expr.Kind == EXPR_REF {
+		self.seed(expr.Target(), rule, seen)
+	}
+}
+
+/*
+	The name expr is traced by, if it is traced
+*/
+func (self *Coverage) ruleName(expr *Expr) (string, bool) {
+	switch {
+	case expr.Kind == EXPR_NODE:
+		return self.types.Name(expr.NodeType), true
+	case expr.Kind == EXPR_LABEL, expr.Kind == EXPR_REF && !isAlias(expr):
+		return expr.Text, true
+	}
+	return "", false
+}
+
+/*
+	The first rule an alternative enters, going down the expressions
+	matching from its start
+*/
+func (self *Coverage) label(expr *Expr) string {
+	for seen := make(map[*Expr]bool); expr != nil && !seen[expr]; {
+		seen[expr] = true
+		if name, ok := self.ruleName(expr); ok {
+			return name
+		}
+		switch expr.Kind {
+		case EXPR_REF:
+			expr = expr.Target()
+		case EXPR_CHOICE, EXPR_EMPTY, EXPR_TERMINAL, EXPR_CLASS, EXPR_OPAQUE:
+			return ""
+		default:
+			if len(expr.Children) == 0 {
+				return ""
+			}
+			expr = expr.Children[0]
+		}
+	}
+	return ""
+}
+
+func (self *Coverage) Trace(event *TraceEvent) {
+	switch event.Kind {
+	case TRACE_ENTER:
+		name := event.Name(self.types)
+		if top := self.top(); top != nil && top.choice != nil && !top.labeled {
+			top.choice.Alternatives[top.alternative].Label = name
+			top.labeled = true
+		}
+		self.stack = append(self.stack, coverageFrame{rule: name})
+	case TRACE_SUCCESS, TRACE_FAILURE:
+		if top := self.top(); top == nil || top.choice != nil {
+			return
+		}
+		self.stack = self.stack[:len(self.stack)-1]
+		rule := self.rule(event.Name(self.types))
+		rule.Calls += 1
+		if event.Kind == TRACE_SUCCESS {
+			rule.Successes += 1
+		}
+	case TRACE_ALTERNATIVE:
+		top := self.top()
+		if top == nil || top.choice == nil || top.id != event.Choice {
+			self.stack = append(self.stack, coverageFrame{choice: self.choice(event), id: event.Choice})
+			top = self.top()
+		}
+		top.alternative = event.Alternative
+		top.labeled = top.choice.Alternatives[event.Alternative].Label != ""
+		top.choice.Alternatives[event.Alternative].Tries += 1
+	case TRACE_CHOICE:
+		top := self.top()
+		if top == nil || top.choice == nil || top.id != event.Choice {
+			return
+		}
+		self.stack = self.stack[:len(self.stack)-1]
+		if event.Alternative < 0 {
+			top.choice.Failures += 1
+		} else {
+			top.choice.Alternatives[event.Alternative].Matches += 1
+		}
+	}
+}
+
+func (self *Coverage) top() *coverageFrame {
+	if len(self.stack) == 0 {
+		return nil
+	}
+	return &self.stack[len(self.stack)-1]
+}
+
+func (self *Coverage) rule(name string) *RuleCoverage {
+	rule := self.rules[name]
+	if rule == nil {
+		rule = &RuleCoverage{Name: name}
+		self.rules[name] = rule
+	}
+	return rule
+}
+
+func (self *Coverage) choice(event *TraceEvent) *ChoiceCoverage {
+	if choice, ok := self.choices[event.Choice]; ok {
+		return choice
+	}
+	rule := ""
+	for i := len(self.stack) - 1; i >= 0; i -= 1 {
+		if self.stack[i].choice == nil {
+			rule = self.stack[i].rule
+			break
+		}
+	}
+	choice := &ChoiceCoverage{Rule: rule, Index: self.indexes[rule]}
+	self.indexes[rule] += 1
+	for i := 0; i < event.Alternatives; i += 1 {
+		choice.Alternatives = append(choice.Alternatives, new(AlternativeCoverage))
+	}
+	self.choices[event.Choice] = choice
+	return choice
+}
+
+/*
+	Returns the rules seen during the parses or added with AddGrammar,
+	plus the node types never specified, by name
+*/
+func (self *Coverage) Rules() []*RuleCoverage {
+	seen := make(map[string]bool)
+	rules := []*RuleCoverage{}
+	for name, rule := range self.rules {
+		seen[name] = true
+		rules = append(rules, rule)
+	}
+	for nodeType, name := range self.types {
+		if nodeType != TYPE_UNDEFINED && !seen[name] {
+			seen[name] = true
+			rules = append(rules, &RuleCoverage{Name: name})
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+	return rules
+}
+
+/*
+	Returns the choices reached during the parses or added with
+	AddGrammar, by rule and index
+*/
+func (self *Coverage) Choices() []*ChoiceCoverage {
+	choices := []*ChoiceCoverage{}
+	for _, choice := range self.choices {
+		choices = append(choices, choice)
+	}
+	sort.Slice(choices, func(i, j int) bool {
+		if choices[i].Rule != choices[j].Rule {
+			return choices[i].Rule < choices[j].Rule
+		}
+		return choices[i].Index < choices[j].Index
+	})
+	return choices
+}
+
+/*
+	Writes the rule and alternative tables, with uncovered entries marked:
+	rule          calls  ok
+	CASE_ELSE     0      0   uncovered
+	...
+	choice        alternative       tries  matches
+	Comparison#0  0 L_COMPARISON    6      0        uncovered
+*/
+func (self *Coverage) WriteReport(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	rules, covered := self.Rules(), 0
+	fmt.Fprintln(table, "rule\tcalls\tok\t")
+	for _, rule := range rules {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%s\n", rule.Name, rule.Calls, rule.Successes, uncovered(rule.Successes))
+		if rule.Successes > 0 {
+			covered += 1
+		}
+	}
+	fmt.Fprintf(table, "\nrules covered: %d/%d\n\n", covered, len(rules))
+
+	alternatives, matched := 0, 0
+	fmt.Fprintln(table, "choice\talternative\ttries\tmatches\t")
+	for _, choice := range self.Choices() {
+		for i, alternative := range choice.Alternatives {
+			fmt.Fprintf(table, "%s\t%d %s\t%d\t%d\t%s\n", choice.Name(), i, alternative.Label,
+				alternative.Tries, alternative.Matches, uncovered(alternative.Matches))
+			alternatives += 1
+			if alternative.Matches > 0 {
+				matched += 1
+			}
+		}
+	}
+	fmt.Fprintf(table, "\nalternatives covered: %d/%d\n", matched, alternatives)
+	return table.Flush()
+}
+
+func uncovered(hits int) string {
+	if hits == 0 {
+		return "uncovered"
+	}
+	return ""
+}
+
+var coverageTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Grammar coverage</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+td, th { padding: 2px 12px; text-align: left; }
+td.hits { text-align: right; }
+tr.covered { background: #dfd; }
+tr.uncovered { background: #fdd; }
+</style>
+</head>
+<body>
+<h1>Rules</h1>
+<table>
+<tr><th>rule</th><th>calls</th><th>ok</th></tr>
+{{range .Rules}}<tr class="{{if .Successes}}covered{{else}}uncovered{{end}}"><td>{{.Name}}</td><td class="hits">{{.Calls}}</td><td class="hits">{{.Successes}}</td></tr>
+{{end}}</table>
+<h1>Choices</h1>
+{{range .Choices}}<h2>{{.Name}}</h2>
+<table>
+<tr><th>#</th><th>alternative</th><th>tries</th><th>matches</th></tr>
+{{range $i, $alternative := .Alternatives}}<tr class="{{if .Matches}}covered{{else}}uncovered{{end}}"><td>{{$i}}</td><td>{{.Label}}</td><td class="hits">{{.Tries}}</td><td class="hits">{{.Matches}}</td></tr>
+{{end}}<tr><td></td><td>no match</td><td></td><td class="hits">{{.Failures}}</td></tr>
+</table>
+{{end}}</body>
+</html>
+`))
+
+/*
+	Writes the report as a standalone HTML page,
+	with covered entries in green and uncovered ones in red
+*/
+func (self *Coverage) WriteHTML(w io.Writer) error {
+	return coverageTemplate.Execute(w, struct {
+		Rules   []*RuleCoverage
+		Choices []*ChoiceCoverage
+	}{self.Rules(), self.Choices()})
+}
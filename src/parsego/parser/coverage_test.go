@@ -0,0 +1,147 @@
+package pg_test
+
+import (
+	"fmt"
+	"parsego/parser"
+	"parsego/parsetree"
+	"strings"
+	"testing"
+)
+
+func cover(coverage *pg.Coverage, rule pg.Parser, inputs ...string) {
+	for _, input := range inputs {
+		in := pg.InitParser()
+		in.SetInput(input)
+		in.SetTracer(coverage)
+		pg.ParseWith(rule, in)
+	}
+}
+
+/*
+	Rules as name calls/ok, choices as name followed by
+	label tries/matches for each alternative, then failures
+*/
+func coverageSummary(coverage *pg.Coverage) string {
+	lines := []string{}
+	for _, rule := range coverage.Rules() {
+		lines = append(lines, fmt.Sprintf("%s %d/%d", rule.Name, rule.Calls, rule.Successes))
+	}
+	for _, choice := range coverage.Choices() {
+		line := choice.Name()
+		for _, alternative := range choice.Alternatives {
+			line += fmt.Sprintf(" %s %d/%d", alternative.Label, alternative.Tries, alternative.Matches)
+		}
+		lines = append(lines, fmt.Sprintf("%s, %d failed", line, choice.Failures))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestCoverage(t *testing.T) {
+	coverage := pg.NewCoverage(pt.NodeTypes{9036: "UNUSED"})
+	cover(coverage, pair(), "ab=c", "a=1", "a=")
+	expected := strings.Join([]string{
+		"UNUSED 0/0",
+		"key 3/3",
+		"number 3/1",
+		"pair 3/2",
+		"word 2/1",
+		"pair#0 number 3/1 word 2/1, 1 failed",
+	}, "\n")
+	if actual := coverageSummary(coverage); actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+
+	var report strings.Builder
+	coverage.WriteReport(&report)
+	for _, line := range []string{"rules covered: 4/5", "alternatives covered: 2/2"} {
+		if !strings.Contains(report.String(), line) {
+			t.Errorf("expected the report to contain %q, got\n%s", line, report.String())
+		}
+	}
+	var page strings.Builder
+	coverage.WriteHTML(&page)
+	if !strings.Contains(page.String(), "<h2>pair#0</h2>") {
+		t.Errorf("expected the page to have a section for pair#0, got\n%s", page.String())
+	}
+
+	coverage.Reset()
+	if actual := coverageSummary(coverage); actual != "UNUSED 0/0" {
+		t.Errorf("expected only uncovered types after Reset, got\n%s", actual)
+	}
+}
+
+/*
+	The same choice built by each call of a rule function is one choice
+*/
+func TestCoverageRebuiltChoice(t *testing.T) {
+	value := func() pg.Parser {
+		return pg.Any(
+			pg.Try(pg.Label("number", pg.Many1(pg.Number()))),
+			pg.Label("word", pg.Many1(pg.Char())))
+	}
+	list := pg.Label("list",
+		pg.Concat(
+			value(),
+			pg.Character(','),
+			value(),
+			pg.Any(
+				pg.Try(pg.Character(';')),
+				pg.Character('.'))))
+	coverage := pg.NewCoverage(nil)
+	cover(coverage, list, "1,a.")
+	expected := strings.Join([]string{
+		"list 1/1",
+		"number 2/1",
+		"word 1/1",
+		"list#0 number 2/1 word 1/1, 0 failed",
+		"list#1  1/0  1/1, 0 failed",
+	}, "\n")
+	if actual := coverageSummary(coverage); actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+/*
+	Rules and choices of the grammar no parse reaches are reported too
+*/
+func TestCoverageGrammar(t *testing.T) {
+	setting := pg.Label("setting",
+		pg.Concat(
+			pair(),
+			pg.Optional(
+				pg.Concat(
+					pg.Character(';'),
+					pg.Any(
+						pg.Try(pg.Label("on", pg.String("on"))),
+						pg.Label("off", pg.String("off")))))))
+	coverage := pg.NewCoverage(nil)
+	coverage.AddGrammar(pg.Describe(setting))
+	cover(coverage, setting, "a=1")
+	expected := strings.Join([]string{
+		"key 1/1",
+		"number 1/1",
+		"off 0/0",
+		"on 0/0",
+		"pair 1/1",
+		"setting 1/1",
+		"word 0/0",
+		"pair#0 number 1/1 word 0/0, 0 failed",
+		"setting#0 on 0/0 off 0/0, 0 failed",
+	}, "\n")
+	if actual := coverageSummary(coverage); actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+
+	var report strings.Builder
+	coverage.WriteReport(&report)
+	for _, line := range []string{"rules covered: 4/7", "alternatives covered: 1/4"} {
+		if !strings.Contains(report.String(), line) {
+			t.Errorf("expected the report to contain %q, got\n%s", line, report.String())
+		}
+	}
+
+	coverage.Reset()
+	if actual := coverageSummary(coverage); !strings.Contains(actual, "setting#0 on 0/0 off 0/0, 0 failed") {
+		t.Errorf("expected Reset to keep the grammar, got\n%s", actual)
+	}
+}
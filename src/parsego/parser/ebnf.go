@@ -0,0 +1,315 @@
+package pg
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+	Loads a grammar written in ISO/IEC 14977 EBNF:
+
+	(* comments are nested in round brackets and stars *)
+	expression = term, { ("+" | "-"), term };
+	term       = number | "(", expression, ")";
+	number     = ["-"], digit, {digit};
+	digit      = "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9";
+
+	with concatenation ",", alternatives "|" (or "/" and "!"), [optional]
+	and {repeated} sequences, groups, 'terminals', "terminals",
+	n * repetitions and exceptions a - b, matching a where b does not match.
+	Definitions end with ";" or ".", and (/ /) and (: :) stand for [ ] and { }.
+	Spaces inside names are ignored by the notation, so "digit excluding
+	zero" is one name. Every rule builds nodes of its own type, numbered in
+	order of definition in Types, the first rule is the start rule.
+
+	Alternatives are tried in order, like PEG choices, so an alternative
+	matching a prefix of a later one must come after it: Check reports the
+	ones that cannot. ? special sequences ? are not supported.
+*/
+func LoadEBNF(src string) (*Grammar, error) {
+	grammar := newGrammar("ebnf")
+	if err := loadEBNF(grammar, src); err != nil {
+		return nil, err
+	}
+	return grammar, nil
+}
+
+/*
+	Loads an EBNF grammar, panicking on errors
+*/
+func MustLoadEBNF(src string) *Grammar {
+	grammar, err := LoadEBNF(src)
+	if err != nil {
+		panic(err)
+	}
+	return grammar
+}
+
+func loadEBNF(grammar *Grammar, src string) error {
+	loader := &ebnfLoader{newTextLoader("ebnf", src, grammar)}
+	if err := loader.parseGrammar(); err != nil {
+		return err
+	}
+	return loader.checkReferences()
+}
+
+/*
+	EBNF parsing
+*/
+
+type ebnfLoader struct {
+	textLoader
+}
+
+func (self *ebnfLoader) skipSpacing() error {
+	for self.position < len(self.source) {
+		switch self.source[self.position] {
+		case ' ', '\t', '\r', '\n', '\f', '\v':
+			self.position += 1
+		case '(':
+			if !strings.HasPrefix(self.source[self.position:], "(*") {
+				return nil
+			}
+			if err := self.skipComment(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (self *ebnfLoader) skipComment() error {
+	start := self.position
+	self.position += 2
+	for depth := 1; depth > 0; {
+		switch {
+		case self.position >= len(self.source):
+			self.position = start
+			return self.errorf("unterminated comment")
+		case strings.HasPrefix(self.source[self.position:], "*)"):
+			depth -= 1
+			self.position += 2
+		case strings.HasPrefix(self.source[self.position:], "(*"):
+			depth += 1
+			self.position += 2
+		default:
+			self.position += 1
+		}
+	}
+	return nil
+}
+
+/*
+	Reads one of the symbols, skipping the spacing after it
+*/
+func (self *ebnfLoader) accept(symbols ...string) (bool, error) {
+	for _, symbol := range symbols {
+		if strings.HasPrefix(self.source[self.position:], symbol) {
+			self.position += len(symbol)
+			return true, self.skipSpacing()
+		}
+	}
+	return false, nil
+}
+
+func (self *ebnfLoader) expect(symbols ...string) error {
+	ok, err := self.accept(symbols...)
+	if err == nil && !ok {
+		return self.unexpected()
+	}
+	return err
+}
+
+/*
+	A name of letters and digits, the words of names with spaces
+	joined by single spaces
+*/
+func (self *ebnfLoader) parseName() (string, error) {
+	words := []string{}
+	for isAlpha(self.peek()) || len(words) > 0 && isDigit(self.peek()) {
+		start := self.position
+		for c := self.peek(); isAlpha(c) || isDigit(c) || c == '_'; c = self.peek() {
+			self.position += 1
+		}
+		words = append(words, self.source[start:self.position])
+		if err := self.skipSpacing(); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(words, " "), nil
+}
+
+func (self *ebnfLoader) parseGrammar() error {
+	if err := self.skipSpacing(); err != nil {
+		return err
+	}
+	for self.position < len(self.source) {
+		start := self.position
+		name, err := self.parseName()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return self.unexpected()
+		}
+		ok, err := self.accept("=")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return self.errorf("expected = after %s", name)
+		}
+		if self.redefines(name) {
+			self.position = start
+			return self.errorf("rule %s redefined", name)
+		}
+		body, err := self.parseDefinitions()
+		if err != nil {
+			return err
+		}
+		if err := self.expect(";", "."); err != nil {
+			return err
+		}
+		self.define(name, body, ruleNode)
+	}
+	return nil
+}
+
+func (self *ebnfLoader) parseDefinitions() (Parser, error) {
+	alternatives := []Parser{}
+	for {
+		definition, err := self.parseDefinition()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, definition)
+		if self.peek() == '/' && strings.HasPrefix(self.source[self.position:], "/)") {
+			return choice(alternatives), nil
+		}
+		if ok, err := self.accept("|", "/", "!"); err != nil || !ok {
+			return choice(alternatives), err
+		}
+	}
+}
+
+func (self *ebnfLoader) parseDefinition() (Parser, error) {
+	items := []Parser{}
+	for {
+		term, err := self.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		if term != nil {
+			items = append(items, term)
+		}
+		if ok, err := self.accept(","); err != nil || !ok {
+			return sequence(items), err
+		}
+	}
+}
+
+/*
+	a - b, the empty term is nil
+*/
+func (self *ebnfLoader) parseTerm() (Parser, error) {
+	factor, err := self.parseFactor()
+	if err != nil || factor == nil {
+		return factor, err
+	}
+	if ok, err := self.accept("-"); err != nil || !ok {
+		return factor, err
+	}
+	exception, err := self.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	if exception == nil {
+		return nil, self.unexpected()
+	}
+	return Concat(Not(exception), factor), nil
+}
+
+func (self *ebnfLoader) parseFactor() (Parser, error) {
+	if !isDigit(self.peek()) {
+		return self.parsePrimary()
+	}
+	start := self.position
+	for isDigit(self.peek()) {
+		self.position += 1
+	}
+	count, _ := strconv.Atoi(self.source[start:self.position])
+	if err := self.skipSpacing(); err != nil {
+		return nil, err
+	}
+	if err := self.expect("*"); err != nil {
+		return nil, err
+	}
+	primary, err := self.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if primary == nil {
+		return nil, self.unexpected()
+	}
+	return repeat(primary, count, count), nil
+}
+
+func (self *ebnfLoader) parsePrimary() (Parser, error) {
+	switch c := self.peek(); {
+	case isAlpha(c):
+		start := self.position
+		name, err := self.parseName()
+		if err != nil {
+			return nil, err
+		}
+		return self.reference(name, start), nil
+	case c == '"' || c == '\'':
+		return self.parseTerminal()
+	case c == '?':
+		return nil, self.errorf("special sequences are not supported")
+	}
+	for _, group := range [][]string{{"(/", "/)"}, {"(:", ":)"}, {"[", "]"}, {"{", "}"}, {"(", ")"}} {
+		ok, err := self.accept(group[0])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		body, err := self.parseDefinitions()
+		if err != nil {
+			return nil, err
+		}
+		if err := self.expect(group[1]); err != nil {
+			return nil, err
+		}
+		switch group[0] {
+		case "(/", "[":
+			return Optional(body), nil
+		case "(:", "{":
+			return Many(body), nil
+		}
+		return body, nil
+	}
+	return nil, nil
+}
+
+func (self *ebnfLoader) parseTerminal() (Parser, error) {
+	quote := self.source[self.position]
+	self.position += 1
+	end := strings.IndexByte(self.source[self.position:], quote)
+	if end < 0 {
+		return nil, self.errorf("unterminated terminal")
+	}
+	text := self.source[self.position : self.position+end]
+	self.position += end + 1
+	if err := self.skipSpacing(); err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return Empty(), nil
+	}
+	return String(text), nil
+}
@@ -0,0 +1,98 @@
+package pg_test
+
+import (
+	"parsego/parser"
+	"strings"
+	"testing"
+)
+
+const expressionGrammar = `
+	(* comments are nested in round brackets and stars *)
+	expression = term, { ("+" | "-"), term };
+	term       = number | "(", expression, ")";
+	number     = ["-"], digit, {digit};
+	digit      = "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9";
+`
+
+func TestLoadEBNF(t *testing.T) {
+	grammar := pg.MustLoadEBNF(expressionGrammar)
+	if names := strings.Join(grammar.Names, " "); names != "expression term number digit" {
+		t.Errorf("expected the rules in order of definition, got %s", names)
+	}
+	out, err := grammar.Parse("-1+(20)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `(1 (2 (3 "-" (4 "1"))) "+" (2 "(" (1 (2 (3 (4 "2") (4 "0")))) ")"))`
+	if actual := sexprs(out); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	if _, err := grammar.Parse("1+"); err == nil || err.Error() != "1:3: unexpected end of input" {
+		t.Errorf("expected a failure at the end of input, got %v", err)
+	}
+}
+
+func TestLoadEBNFExpressions(t *testing.T) {
+	tests := []struct {
+		name     string
+		grammar  string
+		input    string
+		expected string
+	}{
+		{"single quotes", `s = 'a', "b";`, "ab", `(1 "ab")`},
+		{"period", `s = "a".`, "a", `(1 "a")`},
+		{"empty terminal", `s = "", "a";`, "a", `(1 "a")`},
+		{"empty definition", `s = "a", ( | "b");`, "a", `(1 "a")`},
+		{"alternative bars", `s = "a" / "b" ! "c";`, "c", `(1 "c")`},
+		{"optional brackets", `s = (/ "a" /), "b";`, "ab", `(1 "ab")`},
+		{"repeated brackets", `s = (: "a" :), "b";`, "aab", `(1 "aab")`},
+		{"repetition", `s = 3 * "a";`, "aaa", `(1 "aaa")`},
+		{"exception", `s = {letter - "x"}; letter = "a" | "x";`, "aa", `(1 (2 "a") (2 "a"))`},
+		{"names with spaces", `s = digit excluding zero; digit excluding zero = "1";`, "1", `(1 (2 "1"))`},
+		{"nested comments", `s = (* a (* b *) c *) "a";`, "a", `(1 "a")`},
+	}
+	for _, test := range tests {
+		grammar, err := pg.LoadEBNF(test.grammar)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		out, err := grammar.Parse(test.input)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		} else if actual := sexprs(out); actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, actual)
+		}
+	}
+	grammar := pg.MustLoadEBNF(`s = {letter - "x"}; letter = "a" | "x";`)
+	if _, err := grammar.Parse("ax"); err == nil || err.Error() != "1:2: unexpected 'x'" {
+		t.Errorf("expected the exception to fail on x, got %v", err)
+	}
+}
+
+func TestLoadEBNFErrors(t *testing.T) {
+	tests := []struct {
+		grammar  string
+		expected string
+	}{
+		{"", "pg: ebnf 1:1: no rules"},
+		{"s = t;", "pg: ebnf 1:5: undefined rule t"},
+		{"s \"a\";", "pg: ebnf 1:3: expected = after s"},
+		{"s = \"a\";\ns = \"b\";", "pg: ebnf 2:1: rule s redefined"},
+		{"s = \"a\"", "pg: ebnf 1:8: unexpected end of grammar"},
+		{"s = (\"a\";", "pg: ebnf 1:9: unexpected ';'"},
+		{"s = \"a;", "pg: ebnf 1:6: unterminated terminal"},
+		{"s = (* a;", "pg: ebnf 1:5: unterminated comment"},
+		{"s = \"a\" - ;", "pg: ebnf 1:11: unexpected ';'"},
+		{"s = 3 \"a\";", "pg: ebnf 1:7: unexpected '\"'"},
+		{"s = ? special ?;", "pg: ebnf 1:5: special sequences are not supported"},
+	}
+	for _, test := range tests {
+		grammar, err := pg.LoadEBNF(test.grammar)
+		if err == nil {
+			t.Errorf("%q: expected %s, got rules %v", test.grammar, test.expected, grammar.Names)
+		} else if err.Error() != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.grammar, test.expected, err)
+		}
+	}
+}
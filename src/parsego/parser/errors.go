@@ -0,0 +1,99 @@
+package pg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"parsego/parsetree"
+)
+
+var ErrStepBudget = errors.New("step budget exceeded")
+
+var ErrStackDepth = errors.New("stack depth limit exceeded")
+
+/*
+	A failed parse, located at the farthest input position reached.
+	Err is set when the parse was aborted, as with ErrStepBudget
+	and ErrStackDepth.
+*/
+type ParseError struct {
+	Position int
+	Line     int
+	Column   int
+	Message  string
+	Err      error
+}
+
+func (self *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", self.Line, self.Column, self.Message)
+}
+
+func (self *ParseError) Unwrap() error {
+	return self.Err
+}
+
+/*
+	Parses the whole input with match, failing with a *ParseError
+	if match fails or leaves input unconsumed, wrapping ErrStepBudget
+	if DefaultStepBudget runs out first
+*/
+func Parse(match Parser, input string) ([]*pt.ParseTree, error) {
+	in := InitParser()
+	in.SetInput(input)
+	return ParseWith(match, in)
+}
+
+/*
+	Parses the whole input of in like Parse, with in set up by the caller,
+	as with a tracer:
+	in := pg.InitParser()
+	in.SetInput(input)
+	in.SetTracer(pg.NewPrintTracer(os.Stdout, types))
+	out, err := pg.ParseWith(match, in)
+*/
+func ParseWith(match Parser, in *ParseState) ([]*pt.ParseTree, error) {
+	out, ok := match(in)
+	return out, parseResult(in, ok)
+}
+
+/*
+	The error of a parse ending with ok, nil if it consumed all the input
+	and no parser aborted it
+*/
+func parseResult(in *ParseState, ok bool) error {
+	if in.aborted != nil {
+		return in.aborted
+	}
+	if ok && in.GetPosition() == len(in.input) {
+		return nil
+	}
+	// a failed parser may leave the position past the byte it failed on,
+	// which the farthest position points to
+	position := in.GetFarthestPosition()
+	if ok && in.GetPosition() > position {
+		position = in.GetPosition()
+	}
+	err := NewParseError(in.input, position)
+	if in.IsOverBudget() {
+		err.Err = ErrStepBudget
+		err.Message = fmt.Sprintf("%s after %d steps", ErrStepBudget, in.GetSteps())
+	}
+	return err
+}
+
+/*
+	Builds the error for an unexpected input byte at position
+*/
+func NewParseError(input []byte, position int) *ParseError {
+	err := new(ParseError)
+	err.Position = position
+	if position >= len(input) {
+		position = len(input)
+		err.Message = "unexpected end of input"
+	} else {
+		err.Message = fmt.Sprintf("unexpected %q", input[position])
+	}
+	err.Line = bytes.Count(input[:position], []byte{'\n'}) + 1
+	err.Column = position - bytes.LastIndexByte(input[:position], '\n')
+	return err
+}
@@ -0,0 +1,93 @@
+package pg_test
+
+import (
+	"errors"
+	"parsego/parser"
+	"strings"
+	"testing"
+)
+
+/*
+	Errors point at the byte a parse failed on, or at the first byte
+	left over by a successful one
+*/
+func TestParseErrorPosition(t *testing.T) {
+	word := func() pg.Parser {
+		return pg.Many1(pg.CharClass(false, pg.CharRange{'a', 'z'}))
+	}
+	tests := []struct {
+		name     string
+		rule     pg.Parser
+		input    string
+		expected string
+	}{
+		{"first byte", word(), "1", "1:1: unexpected '1'"},
+		{"leftover", word(), "ab1", "1:3: unexpected '1'"},
+		{"second line", word(), "ab\ncd", "1:3: unexpected '\\n'"},
+		{"end of input", pg.Concat(word(), pg.Character(';')), "ab", "1:3: unexpected end of input"},
+		{"trimmed", pg.Trim(word()), " 1 ", "1:2: unexpected '1'"},
+		{"sum", pg.Concat(pg.Number(), pg.Many(pg.Concat(pg.Character('+'), pg.Number()))), "1+x", "1:3: unexpected 'x'"},
+	}
+	for _, test := range tests {
+		parsers := map[string]func(string) error{
+			"closures": func(input string) error {
+				_, err := pg.Parse(test.rule, input)
+				return err
+			},
+			"bytecode": func(input string) error {
+				_, err := pg.CompileBytecode(pg.Describe(test.rule)).Parse(input)
+				return err
+			},
+			"reader": func(input string) error {
+				_, err := pg.ParseReader(test.rule, strings.NewReader(input))
+				return err
+			},
+		}
+		for name, parse := range parsers {
+			err := parse(test.input)
+			if err == nil {
+				t.Errorf("%s, %s: expected %s, got no error", test.name, name, test.expected)
+			} else if err.Error() != test.expected {
+				t.Errorf("%s, %s: expected %s, got %s", test.name, name, test.expected, err)
+			}
+		}
+	}
+}
+
+func TestStepBudget(t *testing.T) {
+	ab := pg.Concat(pg.Character('a'), pg.Character('b'))
+	tests := []struct {
+		name     string
+		rule     pg.Parser
+		input    string
+		budget   int
+		expected string
+	}{
+		{"exhausted", pg.Many(pg.Character('a')), "aaaa", 3, "1:4: step budget exceeded after 3 steps"},
+		{"exact", ab, "ab", 2, ""},
+		{"exact then failing", ab, "ac", 2, "1:2: unexpected 'c'"},
+		{"none", pg.Many(pg.Character('a')), "aaaa", 0, ""},
+	}
+	for _, test := range tests {
+		in := pg.InitParser()
+		in.SetInput(test.input)
+		in.SetStepBudget(test.budget)
+		_, err := pg.ParseWith(test.rule, in)
+		actual := ""
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, actual)
+		}
+		if over := strings.Contains(test.expected, "budget"); errors.Is(err, pg.ErrStepBudget) != over || in.IsOverBudget() != over {
+			t.Errorf("%s: expected over budget %v, got %v", test.name, over, in.IsOverBudget())
+		}
+	}
+
+	defer func(budget int) { pg.DefaultStepBudget = budget }(pg.DefaultStepBudget)
+	pg.DefaultStepBudget = 2
+	if _, err := pg.Parse(pg.Many(pg.Character('a')), "aaa"); !errors.Is(err, pg.ErrStepBudget) {
+		t.Errorf("expected Parse to run out of DefaultStepBudget, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package pg
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const (
+	GENERATE_ATTEMPTS    = 100
+	GENERATE_REPETITIONS = 3
+)
+
+const unreachable = 1 << 30
+
+/*
+	Generates a random sentence accepted by rule, for fuzzing.
+	maxDepth bounds the nesting of Specify and Recursive rules: past it,
+	choices take the alternatives closest to a terminal and repetitions stop.
+	Ordered choice, greedy repetition and the & and ! predicates, which
+	generate nothing, make some generated sentences parse differently,
+	so candidates are checked with Parse and retried up to
+	GENERATE_ATTEMPTS times.
+*/
+func Generate(rule Parser, random *rand.Rand, maxDepth int) (string, error) {
+	expr := Describe(rule)
+	generator := &generator{random: random, maxDepth: maxDepth, costs: exprCosts(expr)}
+	if generator.costs[expr] >= unreachable {
+		return "", fmt.Errorf("pg: cannot generate from parsers not built from combinators")
+	}
+	for attempt := 0; attempt < GENERATE_ATTEMPTS; attempt += 1 {
+		generator.out.Reset()
+		generator.generate(expr, 0)
+		sentence := generator.out.String()
+		if _, err := Parse(rule, sentence); err == nil {
+			return sentence, nil
+		}
+	}
+	return "", fmt.Errorf("pg: no accepted sentence in %d attempts", GENERATE_ATTEMPTS)
+}
+
+type generator struct {
+	random   *rand.Rand
+	maxDepth int
+	costs    map[*Expr]int
+	out      strings.Builder
+}
+
+func (self *generator) generate(expr *Expr, depth int) {
+	switch expr.Kind {
+	case EXPR_TERMINAL:
+		self.out.WriteString(expr.Text)
+	case EXPR_CLASS:
+		self.out.WriteByte(self.pick(expr))
+	case EXPR_SEQ, EXPR_BETWEEN:
+		for _, child := range expr.Children {
+			self.generate(child, depth)
+		}
+	case EXPR_CHOICE:
+		self.generate(self.alternative(expr, depth), depth)
+	case EXPR_STAR, EXPR_PLUS:
+		count := 0
+		if depth < self.maxDepth {
+			count = self.random.Intn(GENERATE_REPETITIONS + 1)
+		}
+		if expr.Kind == EXPR_PLUS && count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i += 1 {
+			self.generate(expr.Children[0], depth)
+		}
+	case EXPR_OPTIONAL:
+		if depth < self.maxDepth && self.random.Intn(2) == 0 {
+			self.generate(expr.Children[0], depth)
+		}
+	case EXPR_TRY, EXPR_SKIP, EXPR_LABEL:
+		self.generate(expr.Children[0], depth)
+	case EXPR_NODE:
+		self.generate(expr.Children[0], depth+1)
+	case EXPR_REF:
+		self.generate(expr.Target(), depth+1)
+	}
+}
+
+/*
+	A random alternative that can complete within maxDepth,
+	or the cheapest one
+*/
+func (self *generator) alternative(expr *Expr, depth int) *Expr {
+	candidates := []*Expr{}
+	cheapest := expr.Children[0]
+	for _, child := range expr.Children {
+		if depth+self.costs[child] <= self.maxDepth {
+			candidates = append(candidates, child)
+		}
+		if self.costs[child] < self.costs[cheapest] {
+			cheapest = child
+		}
+	}
+	if len(candidates) == 0 {
+		return cheapest
+	}
+	return candidates[self.random.Intn(len(candidates))]
+}
+
+func (self *generator) pick(expr *Expr) byte {
+	candidates := []byte{}
+	for c := 0; c < 256; c += 1 {
+		if expr.Matches(byte(c)) && (!expr.Negated || c >= ' ' && c <= '~') {
+			candidates = append(candidates, byte(c))
+		}
+	}
+	if len(candidates) == 0 {
+		return 0
+	}
+	return candidates[self.random.Intn(len(candidates))]
+}
+
+/*
+	The number of nested Specify and Recursive rules to go through before
+	reaching terminals, for every expression reachable from root
+*/
+func exprCosts(root *Expr) map[*Expr]int {
+	exprs := []*Expr{}
+	costs := make(map[*Expr]int)
+	var collect func(expr *Expr)
+	collect = func(expr *Expr) {
+		if expr == nil {
+			return
+		}
+		if _, ok := costs[expr]; ok {
+			return
+		}
+		costs[expr] = unreachable
+		exprs = append(exprs, expr)
+		for _, child := range expr.Children {
+			collect(child)
+		}
+		if expr.Kind == EXPR_REF {
+			collect(expr.Target())
+		}
+	}
+	collect(root)
+
+	for changed := true; changed; {
+		changed = false
+		for _, expr := range exprs {
+			if cost := exprCost(expr, costs); cost < costs[expr] {
+				costs[expr] = cost
+				changed = true
+			}
+		}
+	}
+	return costs
+}
+
+func exprCost(expr *Expr, costs map[*Expr]int) int {
+	switch expr.Kind {
+	case EXPR_EMPTY, EXPR_TERMINAL, EXPR_CLASS, EXPR_STAR, EXPR_OPTIONAL, EXPR_AND, EXPR_NOT:
+		return 0
+	case EXPR_SEQ, EXPR_BETWEEN:
+		cost := 0
+		for _, child := range expr.Children {
+			if costs[child] > cost {
+				cost = costs[child]
+			}
+		}
+		return cost
+	case EXPR_CHOICE:
+		cost := unreachable
+		for _, child := range expr.Children {
+			if costs[child] < cost {
+				cost = costs[child]
+			}
+		}
+		return cost
+	case EXPR_PLUS, EXPR_TRY, EXPR_SKIP, EXPR_LABEL:
+		return costs[expr.Children[0]]
+	case EXPR_NODE:
+		if costs[expr.Children[0]] < unreachable {
+			return costs[expr.Children[0]] + 1
+		}
+	case EXPR_REF:
+		if target := expr.Target(); target != nil && costs[target] < unreachable {
+			return costs[target] + 1
+		}
+	}
+	return unreachable
+}
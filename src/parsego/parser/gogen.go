@@ -0,0 +1,333 @@
+package pg
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"parsego/parsetree"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+	Writes a standalone Go parser for the grammar reachable from root,
+	as package pkg depending only on the standard library:
+
+	func Parse(input string) ([]*ParseTree, error)
+	var NodeTypes = map[int]string{...}
+
+	Its ParseTree, InputPosition and ParseError mirror pt.ParseTree (without
+	the Actual fields), pt.InputPosition and ParseError, and Parse builds the
+	same trees and errors as the pg Parse of root, with no tracer and no step
+	budget. Every expression becomes a function reading the input directly,
+	instead of a closure calling the State interface.
+	Grammars written in Go are generated through Describe:
+	pg.WriteGo(out, pg.Describe(Program()), NODE_TYPES, "program")
+	Parsers not built from combinators cannot be generated.
+*/
+func WriteGo(w io.Writer, root *Expr, types pt.NodeTypes, pkg string) error {
+	generator := &goGenerator{types: types, classes: newExprClasses()}
+	var opaque *Expr
+	root.Walk(func(expr *Expr) bool {
+		switch expr.Kind {
+		case EXPR_OPAQUE:
+			opaque = expr
+		case EXPR_REF, EXPR_LABEL:
+		default:
+			generator.classes.id(expr)
+		}
+		return true
+	})
+	if opaque != nil {
+		return fmt.Errorf("pg: cannot generate Go for parsers not built from combinators")
+	}
+
+	generator.writeHeader(pkg)
+	fmt.Fprintf(&generator.out, "\nfunc Parse(input string) ([]*ParseTree, error) {\n")
+	fmt.Fprintf(&generator.out, "p := &parser{input: []byte(input), line: 1}\nout, ok := %s\n", generator.call(root))
+	generator.out.WriteString(goParseEnd)
+	for _, expr := range generator.classes.exprs {
+		generator.writeExpr(expr)
+	}
+	if generator.fails {
+		generator.out.WriteString("\nfunc (p *parser) fail() ([]*ParseTree, bool) {\nreturn nil, false\n}\n")
+	}
+
+	source, err := format.Source([]byte(generator.out.String()))
+	if err != nil {
+		return fmt.Errorf("pg: generated invalid Go: %s", err)
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+type goGenerator struct {
+	out     strings.Builder
+	types   pt.NodeTypes
+	classes *exprClasses
+	fails   bool
+}
+
+func (self *goGenerator) call(expr *Expr) string {
+	target, _ := resolveRule(expr)
+	if target == nil {
+		self.fails = true
+		return "p.fail()"
+	}
+	return fmt.Sprintf("p.e%d()", self.classes.id(target))
+}
+
+func (self *goGenerator) writeHeader(pkg string) {
+	fmt.Fprintf(&self.out, "// Code generated by parsego gen. DO NOT EDIT.\n\npackage %s\n", pkg)
+	self.out.WriteString(goRuntime)
+
+	nodeTypes := []int{}
+	for nodeType := range self.types {
+		nodeTypes = append(nodeTypes, nodeType)
+	}
+	sort.Ints(nodeTypes)
+	self.out.WriteString("\nvar NodeTypes = map[int]string{\n")
+	for _, nodeType := range nodeTypes {
+		fmt.Fprintf(&self.out, "%d: %q,\n", nodeType, self.types[nodeType])
+	}
+	self.out.WriteString("}\n")
+}
+
+func (self *goGenerator) writeExpr(expr *Expr) {
+	out := &self.out
+	id := self.classes.id(expr)
+	comment := expr.Format(self.types)
+	if expr.Kind == EXPR_NODE {
+		comment += " <- " + expr.Children[0].Format(self.types)
+	}
+	if runes := []rune(comment); len(runes) > 72 {
+		comment = string(runes[:69]) + "..."
+	}
+	fmt.Fprintf(out, "\n// %s\nfunc (p *parser) e%d() ([]*ParseTree, bool) {\n", comment, id)
+
+	calls := []string{}
+	for _, child := range expr.Children {
+		calls = append(calls, self.call(child))
+	}
+	switch expr.Kind {
+	case EXPR_EMPTY:
+		out.WriteString("return nil, true\n")
+	case EXPR_TERMINAL:
+		fmt.Fprintf(out, "start := p.position\nif !p.literal(%q) {\nreturn nil, false\n}\nreturn p.leaf(start), true\n", expr.Text)
+	case EXPR_CLASS:
+		// c is only declared when the class tests it, unused it would not compile
+		switch condition := goClassCondition(expr); condition {
+		case "false":
+			out.WriteString("p.next()\nreturn nil, false\n")
+		case "true":
+			out.WriteString("_, ok := p.next()\nif !ok {\nreturn nil, false\n}\nreturn p.leaf(p.position - 1), true\n")
+		default:
+			fmt.Fprintf(out, "c, ok := p.next()\nif !ok || !(%s) {\nreturn nil, false\n}\nreturn p.leaf(p.position - 1), true\n", condition)
+		}
+	case EXPR_SEQ:
+		out.WriteString("var nodes []*ParseTree\n")
+		for i, call := range calls {
+			assign := "="
+			if i == 0 {
+				assign = ":="
+			}
+			fmt.Fprintf(out, "out, ok %s %s\nif !ok {\nreturn nil, false\n}\nnodes = concat(nodes, out)\n", assign, call)
+		}
+		out.WriteString("return nodes, true\n")
+	case EXPR_CHOICE:
+		for _, call := range calls {
+			fmt.Fprintf(out, "if out, ok := %s; ok {\nreturn out, true\n}\n", call)
+		}
+		out.WriteString("return nil, false\n")
+	case EXPR_STAR, EXPR_PLUS:
+		out.WriteString("var nodes []*ParseTree\n")
+		if expr.Kind == EXPR_PLUS {
+			fmt.Fprintf(out, "out, ok := %s\nif !ok {\nreturn nil, false\n}\nnodes = concat(nodes, out)\n", calls[0])
+		}
+		fmt.Fprintf(out, `for {
+			position, line := p.position, p.line
+			out, ok := %s
+			if !ok {
+				p.position, p.line = position, line
+				break
+			}
+			nodes = concat(nodes, out)
+			if p.position == position {
+				break
+			}
+		}
+		return nodes, true
+		`, calls[0])
+	case EXPR_TRY:
+		fmt.Fprintf(out, "position, line := p.position, p.line\nout, ok := %s\nif !ok {\np.position, p.line = position, line\n}\nreturn out, ok\n", calls[0])
+	case EXPR_OPTIONAL:
+		fmt.Fprintf(out, "position, line := p.position, p.line\nif out, ok := %s; ok {\nreturn out, true\n}\np.position, p.line = position, line\nreturn nil, true\n", calls[0])
+	case EXPR_AND, EXPR_NOT:
+		expected := "ok"
+		if expr.Kind == EXPR_NOT {
+			expected = "!ok"
+		}
+		fmt.Fprintf(out, "position, line := p.position, p.line\n_, ok := %s\np.position, p.line = position, line\nreturn nil, %s\n", calls[0], expected)
+	case EXPR_SKIP:
+		fmt.Fprintf(out, "_, ok := %s\nreturn nil, ok\n", calls[0])
+	case EXPR_BETWEEN:
+		fmt.Fprintf(out, "if _, ok := %s; !ok {\nreturn nil, false\n}\nout, ok := %s\nif !ok {\nreturn nil, false\n}\nif _, ok := %s; !ok {\nreturn nil, false\n}\nreturn out, true\n", calls[0], calls[1], calls[2])
+	case EXPR_NODE:
+		fmt.Fprintf(out, "position, line := p.position, p.line\nout, ok := %s\nif !ok {\nreturn nil, false\n}\nreturn p.node(%d, position, line, out), true\n", calls[0], expr.NodeType)
+	}
+	out.WriteString("}\n")
+}
+
+func goClassCondition(expr *Expr) string {
+	tests := []string{}
+	for _, r := range expr.Ranges {
+		switch {
+		case r.Low == r.High:
+			tests = append(tests, "c == "+goByte(r.Low))
+		case r.Low == 0 && r.High == 0xff:
+			tests = append(tests, "true")
+		case r.Low == 0:
+			tests = append(tests, "c <= "+goByte(r.High))
+		case r.High == 0xff:
+			tests = append(tests, "c >= "+goByte(r.Low))
+		default:
+			tests = append(tests, fmt.Sprintf("c >= %s && c <= %s", goByte(r.Low), goByte(r.High)))
+		}
+	}
+	switch {
+	case len(tests) == 0 && expr.Negated:
+		return "true"
+	case len(tests) == 0:
+		return "false"
+	case expr.Negated:
+		return "!(" + strings.Join(tests, " || ") + ")"
+	}
+	return strings.Join(tests, " || ")
+}
+
+func goByte(c byte) string {
+	if c < 0x80 {
+		return strconv.QuoteRune(rune(c))
+	}
+	return fmt.Sprintf("0x%02x", c)
+}
+
+/*
+	The fixed part of generated parsers, mirroring ParseState.Next,
+	concat and NewParseError
+*/
+const goRuntime = `
+import (
+	"bytes"
+	"fmt"
+)
+
+type ParseTree struct {
+	Value    []byte
+	Type     int
+	Children []*ParseTree
+	Position InputPosition
+}
+
+type InputPosition struct {
+	StartPosition int
+	EndPosition   int
+	StartLine     int
+	EndLine       int
+}
+
+type ParseError struct {
+	Position int
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+type parser struct {
+	input    []byte
+	position int
+	line     int
+	farthest int
+}
+
+func (p *parser) next() (byte, bool) {
+	if p.position > p.farthest {
+		p.farthest = p.position
+	}
+	if p.position >= len(p.input) {
+		return 0, false
+	}
+	c := p.input[p.position]
+	p.position++
+	if c == '\n' {
+		p.line++
+	}
+	return c, true
+}
+
+func (p *parser) literal(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if c, ok := p.next(); !ok || c != text[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) leaf(start int) []*ParseTree {
+	return []*ParseTree{{Value: p.input[start:p.position:p.position]}}
+}
+
+func (p *parser) node(nodeType, position, line int, out []*ParseTree) []*ParseTree {
+	node := &ParseTree{Type: nodeType}
+	node.Position = InputPosition{position, p.position, line, p.line}
+	if len(out) == 1 && out[0].Type == 0 {
+		node.Value = out[0].Value
+	} else if len(out) > 0 {
+		node.Children = out
+	}
+	return []*ParseTree{node}
+}
+
+func concat(a, b []*ParseTree) []*ParseTree {
+	if len(a) == 1 && len(b) == 1 && a[0].Type == 0 && b[0].Type == 0 {
+		a[0].Value = concatBytes(a[0].Value, b[0].Value)
+		return a
+	}
+	return append(a, b...)
+}
+
+// leaves are slices of the input capped at their end, merges copy them once
+// to a buffer of their own and then grow it in place
+func concatBytes(a, b []byte) []byte {
+	if len(a) == 0 {
+		return b
+	}
+	return append(a, b...)
+}
+`
+
+const goParseEnd = `if ok && p.position == len(p.input) {
+		return out, nil
+	}
+	position := p.farthest
+	if ok && p.position > position {
+		position = p.position
+	}
+	err := &ParseError{Position: position}
+	if position >= len(p.input) {
+		position = len(p.input)
+		err.Message = "unexpected end of input"
+	} else {
+		err.Message = fmt.Sprintf("unexpected %q", p.input[position])
+	}
+	err.Line = bytes.Count(p.input[:position], []byte{'\n'}) + 1
+	err.Column = position - bytes.LastIndexByte(p.input[:position], '\n')
+	return out, err
+}
+`
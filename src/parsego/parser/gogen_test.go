@@ -0,0 +1,40 @@
+package pg_test
+
+import (
+	"os"
+	"parsego/examples/config"
+	"parsego/parser"
+	"parsego/parsetree"
+	"parsego/pgtest"
+	"testing"
+)
+
+/*
+	The parser generated from examples/config/config.peg, a grammar of
+	lookaheads, options and classes including ., parses as the grammar
+*/
+func TestGeneratedConfig(t *testing.T) {
+	source, err := os.ReadFile("../examples/config/config.peg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grammar, err := pg.LoadPEG(string(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgtest.Generated(t, "../examples/config/parser.go", "config", grammar.Start(), grammar.Types)
+	inputs := []string{
+		"",
+		"name = parsego\n",
+		"# settings\n\nkey=\"quoted # value\"  # comment\nempty =\nlast = bare",
+		"dotted.key = 1 # one\n\t\n",
+		"= no key\n",
+		"key = \"unterminated\n",
+		"1key = 2\n",
+	}
+	inputs = append(inputs, pgtest.Sentences(grammar.Start(), 100, 8)...)
+	pgtest.Equivalent(t, grammar.Start(), grammar.Types, func(input string) ([]*pt.ParseTree, error) {
+		out, err := config.Parse(input)
+		return pgtest.Convert(out), err
+	}, inputs...)
+}
@@ -0,0 +1,507 @@
+package pg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"parsego/parsetree"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	EXPR_OPAQUE = iota
+	EXPR_EMPTY
+	EXPR_TERMINAL
+	EXPR_CLASS
+	EXPR_SEQ
+	EXPR_CHOICE
+	EXPR_STAR
+	EXPR_PLUS
+	EXPR_TRY
+	EXPR_SKIP
+	EXPR_BETWEEN
+	EXPR_NODE
+	EXPR_REF
+	EXPR_LABEL
+	EXPR_OPTIONAL
+	EXPR_AND
+	EXPR_NOT
+)
+
+/*
+	A node of the grammar graph built by the pg combinators,
+	see Describe and Compile.
+	Text is the matched string for EXPR_TERMINAL and the name for EXPR_REF
+	and EXPR_LABEL, NodeType is set for EXPR_NODE.
+	Parser is the compiled parser, or the parser itself for EXPR_OPAQUE,
+	used to describe parsers not built from combinators.
+*/
+type Expr struct {
+	Kind     int
+	Children []*Expr
+	Text     string
+	Ranges   []CharRange
+	Negated  bool
+	NodeType int
+	Parser   Parser
+	resolve  func() *Expr
+	target   *Expr
+}
+
+/*
+	Inclusive byte range of an EXPR_CLASS
+*/
+type CharRange struct {
+	Low  byte
+	High byte
+}
+
+/*
+	Reports whether c belongs to an EXPR_CLASS
+*/
+func (self *Expr) Matches(c byte) bool {
+	for _, r := range self.Ranges {
+		if c >= r.Low && c <= r.High {
+			return !self.Negated
+		}
+	}
+	return self.Negated
+}
+
+/*
+	The rule referenced by an EXPR_REF, built on first use
+*/
+func (self *Expr) Target() *Expr {
+	if self.target == nil && self.resolve != nil {
+		self.target = self.resolve()
+	}
+	return self.target
+}
+
+/*
+	Visits every expression reachable from self once, in pre-order,
+	going from EXPR_REF to their targets.
+	Children are skipped when visit returns false.
+*/
+func (self *Expr) Walk(visit func(expr *Expr) bool) {
+	visited := make(map[*Expr]bool)
+	var walk func(expr *Expr)
+	walk = func(expr *Expr) {
+		if expr == nil || visited[expr] {
+			return
+		}
+		visited[expr] = true
+		if !visit(expr) {
+			return
+		}
+		for _, child := range expr.Children {
+			walk(child)
+		}
+		if expr.Kind == EXPR_REF {
+			walk(expr.Target())
+		}
+	}
+	walk(self)
+}
+
+/*
+	Numbers expressions by kind, content and children, as grammars built
+	in Go repeat the same expressions, like every call to Whitespaces.
+	Rules are told apart by identity, as they may be recursive, and rule
+	references and labels are skipped, as they only matter to tracing.
+	exprs holds the first expression of each number.
+*/
+type exprClasses struct {
+	classes map[string]int
+	ids     map[*Expr]int
+	exprs   []*Expr
+}
+
+func newExprClasses() *exprClasses {
+	return &exprClasses{classes: make(map[string]int), ids: make(map[*Expr]int)}
+}
+
+func (self *exprClasses) id(expr *Expr) int {
+	if id, ok := self.ids[expr]; ok {
+		return id
+	}
+	key := fmt.Sprintf("%d %q %v %t %d", expr.Kind, expr.Text, expr.Ranges, expr.Negated, expr.NodeType)
+	if expr.Kind == EXPR_OPAQUE {
+		key += fmt.Sprintf(" %p", expr)
+	}
+	for _, child := range expr.Children {
+		switch target, rule := resolveRule(child); {
+		case target == nil:
+			key += " fail"
+		case rule:
+			key += fmt.Sprintf(" @%p", target)
+		default:
+			key += fmt.Sprintf(" %d", self.id(target))
+		}
+	}
+	id, ok := self.classes[key]
+	if !ok {
+		id = len(self.exprs)
+		self.classes[key] = id
+		self.exprs = append(self.exprs, expr)
+	}
+	self.ids[expr] = id
+	return id
+}
+
+/*
+	Whether expr only refers to a rule named on its own, by Specify
+	or Label, which stands for the reference in traces and checks
+*/
+func isAlias(expr *Expr) bool {
+	if expr.Kind != EXPR_REF {
+		return false
+	}
+	target := expr.Target()
+	return target != nil && (target.Kind == EXPR_NODE || target.Kind == EXPR_LABEL)
+}
+
+/*
+	Goes through rule references and labels, telling whether a reference
+	was crossed, nil for undefined rules
+*/
+func resolveRule(expr *Expr) (*Expr, bool) {
+	rule := false
+	for seen := make(map[*Expr]bool); expr != nil && !seen[expr]; {
+		seen[expr] = true
+		switch expr.Kind {
+		case EXPR_REF:
+			expr = expr.Target()
+			rule = true
+		case EXPR_LABEL:
+			expr = expr.Children[0]
+		default:
+			return expr, rule
+		}
+	}
+	return nil, rule
+}
+
+/*
+	The name of EXPR_NODE, EXPR_REF and EXPR_LABEL expressions, "" for others
+*/
+func (self *Expr) Name(types pt.NodeTypes) string {
+	switch self.Kind {
+	case EXPR_NODE:
+		return types.Name(self.NodeType)
+	case EXPR_REF, EXPR_LABEL:
+		return self.Text
+	}
+	return ""
+}
+
+/*
+	Renders the expression in PEG notation, named rules by name:
+	"func" Whitespaces IDENTIFIER ("(" / "[")
+	Try, Skip and Between only change the trees built,
+	so they print as their content.
+*/
+func (self *Expr) Format(types pt.NodeTypes) string {
+	var out strings.Builder
+	formatExpr(&out, self, PREC_CHOICE, types)
+	return out.String()
+}
+
+func (self *Expr) String() string {
+	return self.Format(nil)
+}
+
+const (
+	PREC_CHOICE = iota
+	PREC_SEQ
+	PREC_PREFIX
+	PREC_SUFFIX
+	PREC_PRIMARY
+)
+
+func precedence(expr *Expr) int {
+	switch expr.Kind {
+	case EXPR_CHOICE:
+		if len(expr.Children) > 1 {
+			return PREC_CHOICE
+		}
+		return precedence(expr.Children[0])
+	case EXPR_SEQ:
+		if len(expr.Children) > 1 {
+			return PREC_SEQ
+		}
+		if len(expr.Children) == 1 {
+			return precedence(expr.Children[0])
+		}
+	case EXPR_BETWEEN:
+		return PREC_SEQ
+	case EXPR_AND, EXPR_NOT:
+		return PREC_PREFIX
+	case EXPR_STAR, EXPR_PLUS, EXPR_OPTIONAL:
+		return PREC_SUFFIX
+	case EXPR_TRY, EXPR_SKIP:
+		return precedence(expr.Children[0])
+	}
+	return PREC_PRIMARY
+}
+
+func formatExpr(out *strings.Builder, expr *Expr, min int, types pt.NodeTypes) {
+	if precedence(expr) < min {
+		out.WriteString("(")
+		formatExpr(out, expr, PREC_CHOICE, types)
+		out.WriteString(")")
+		return
+	}
+	switch expr.Kind {
+	case EXPR_EMPTY:
+		out.WriteString(`""`)
+	case EXPR_TERMINAL:
+		out.WriteString(strconv.Quote(expr.Text))
+	case EXPR_CLASS:
+		out.WriteString(formatClass(expr))
+	case EXPR_SEQ, EXPR_BETWEEN:
+		if len(expr.Children) == 0 {
+			out.WriteString(`""`)
+		}
+		for i, child := range expr.Children {
+			if i > 0 {
+				out.WriteString(" ")
+			}
+			formatExpr(out, child, PREC_SEQ, types)
+		}
+	case EXPR_CHOICE:
+		for i, child := range expr.Children {
+			if i > 0 {
+				out.WriteString(" / ")
+			}
+			formatExpr(out, child, PREC_CHOICE, types)
+		}
+	case EXPR_STAR, EXPR_PLUS, EXPR_OPTIONAL:
+		formatExpr(out, expr.Children[0], PREC_PRIMARY, types)
+		switch expr.Kind {
+		case EXPR_STAR:
+			out.WriteString("*")
+		case EXPR_PLUS:
+			out.WriteString("+")
+		default:
+			out.WriteString("?")
+		}
+	case EXPR_AND, EXPR_NOT:
+		if expr.Kind == EXPR_AND {
+			out.WriteString("&")
+		} else {
+			out.WriteString("!")
+		}
+		formatExpr(out, expr.Children[0], PREC_SUFFIX, types)
+	case EXPR_TRY, EXPR_SKIP:
+		formatExpr(out, expr.Children[0], min, types)
+	case EXPR_NODE, EXPR_REF, EXPR_LABEL:
+		out.WriteString(expr.Name(types))
+	default:
+		out.WriteString("<opaque>")
+	}
+}
+
+func formatClass(expr *Expr) string {
+	if expr.Negated && len(expr.Ranges) == 0 {
+		return "."
+	}
+	var out strings.Builder
+	out.WriteString("[")
+	if expr.Negated {
+		out.WriteString("^")
+	}
+	for _, r := range expr.Ranges {
+		out.WriteString(escapeClassChar(r.Low))
+		if r.High != r.Low {
+			out.WriteString("-")
+			out.WriteString(escapeClassChar(r.High))
+		}
+	}
+	out.WriteString("]")
+	return out.String()
+}
+
+func escapeClassChar(c byte) string {
+	switch c {
+	case '\\', ']', '[', '^', '-':
+		return "\\" + string(c)
+	case '\n':
+		return "\\n"
+	case '\r':
+		return "\\r"
+	case '\t':
+		return "\\t"
+	}
+	if c < ' ' || c > '~' {
+		return fmt.Sprintf("\\%03o", c)
+	}
+	return string(c)
+}
+
+/*
+	Writes the rules reachable from root in PEG notation, one per line,
+	starting from root, named "Start" unless it is a named rule:
+	PROGRAM <- Whitespaces (FUNCTION_DEFINITION / Statement)*
+	Rules sharing a name are written once.
+*/
+func WritePEG(w io.Writer, root *Expr, types pt.NodeTypes) error {
+	out := bufio.NewWriter(w)
+	if root.Name(types) == "" {
+		fmt.Fprintf(out, "Start <- %s\n", root.Format(types))
+	}
+	written := make(map[string]bool)
+	root.Walk(func(expr *Expr) bool {
+		name := expr.Name(types)
+		if name == "" || written[name] {
+			return true
+		}
+		written[name] = true
+		body := expr.Target()
+		if expr.Kind != EXPR_REF {
+			body = expr.Children[0]
+		}
+		for body != nil && body.Kind != EXPR_REF && body.Name(types) == name {
+			body = body.Children[0]
+		}
+		if body == nil {
+			fmt.Fprintf(out, "%s <- <undefined>\n", name)
+		} else {
+			fmt.Fprintf(out, "%s <- %s\n", name, body.Format(types))
+		}
+		return true
+	})
+	return out.Flush()
+}
+
+/*
+	Returns the structure of match.
+	Describe runs the parsers of this package on a State of its own,
+	which they answer with their Expr. Any other parser is EXPR_OPAQUE,
+	and never run: it may wrap a combinator, doing more than it does.
+*/
+func Describe(match Parser) *Expr {
+	if !isCombinator(match) {
+		return &Expr{Kind: EXPR_OPAQUE, Parser: match}
+	}
+	probe := new(exprProbe)
+	if !probe.run(match) || probe.answers != 1 || probe.touched {
+		return &Expr{Kind: EXPR_OPAQUE, Parser: match}
+	}
+	return probe.expr
+}
+
+/*
+	The start of the names of the functions of this package,
+	as "parsego/parser."
+*/
+var combinatorPrefix = strings.TrimSuffix(functionName(describing), "describing")
+
+func functionName(function interface{}) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(function).Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+/*
+	Whether match is a closure of this package, a combinator or a parser
+	answering the probe as one, rather than a parser of the user
+*/
+func isCombinator(match Parser) bool {
+	return match != nil && strings.HasPrefix(functionName(match), combinatorPrefix)
+}
+
+func describeAll(matches []Parser) []*Expr {
+	exprs := []*Expr{}
+	for _, match := range matches {
+		exprs = append(exprs, Describe(match))
+	}
+	return exprs
+}
+
+/*
+	Every combinator answers this State with its Expr, instead of parsing.
+	Parsers using the State for anything else are opaque.
+*/
+type exprProbe struct {
+	expr    *Expr
+	answers int
+	touched bool
+}
+
+/*
+	Runs match on the probe, false if it panicked
+*/
+func (self *exprProbe) run(match Parser) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	match(self)
+	return true
+}
+
+func describing(in State, expr *Expr) bool {
+	probe, ok := in.(*exprProbe)
+	if ok {
+		probe.expr = expr
+		probe.answers += 1
+	}
+	return ok
+}
+
+/*
+	Makes the parser running on in EXPR_OPAQUE when in is the probe,
+	so combinators keep calling it instead of compiling its Expr
+*/
+func opaque(in State) bool {
+	probe, ok := in.(*exprProbe)
+	if ok {
+		probe.touched = true
+	}
+	return ok
+}
+
+func (self *exprProbe) Next() (int, bool) {
+	self.touched = true
+	return 0, false
+}
+
+func (self *exprProbe) SetInput(in string) {
+	self.touched = true
+}
+
+func (self *exprProbe) GetInput() string {
+	self.touched = true
+	return ""
+}
+
+func (self *exprProbe) GetPosition() int {
+	self.touched = true
+	return 0
+}
+
+func (self *exprProbe) SetPosition(position int) {
+	self.touched = true
+}
+
+func (self *exprProbe) SetLineCount(lineCount int) {
+	self.touched = true
+}
+
+func (self *exprProbe) GetLineCount() int {
+	self.touched = true
+	return 1
+}
+
+func (self *exprProbe) GetProbeCount() int {
+	self.touched = true
+	return 0
+}
+
+var _ State = (*exprProbe)(nil)
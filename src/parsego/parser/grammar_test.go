@@ -0,0 +1,161 @@
+package pg_test
+
+import (
+	"parsego/parser"
+	"parsego/parsetree"
+	"strings"
+	"testing"
+)
+
+/*
+	A parser of its own, as users write, expecting a *ParseState
+*/
+func upperCase(in pg.State) ([]*pt.ParseTree, bool) {
+	state := in.(*pg.ParseState)
+	c, ok := state.Next()
+	if !ok || c < 'A' || c > 'Z' {
+		return nil, false
+	}
+	return []*pt.ParseTree{{Value: []byte{byte(c)}}}, true
+}
+
+func TestDescribeOpaque(t *testing.T) {
+	if kind := pg.Describe(upperCase).Kind; kind != pg.EXPR_OPAQUE {
+		t.Errorf("expected a panicking parser to be opaque, got kind %d", kind)
+	}
+	reading := func(in pg.State) ([]*pt.ParseTree, bool) {
+		in.Next()
+		return nil, true
+	}
+	if kind := pg.Describe(reading).Kind; kind != pg.EXPR_OPAQUE {
+		t.Errorf("expected a parser reading the input to be opaque, got kind %d", kind)
+	}
+	if kind := pg.Describe(pg.Char()).Kind; kind == pg.EXPR_OPAQUE {
+		t.Error("expected Char to be described")
+	}
+
+	rule := pg.Concat(pg.Parser(upperCase), pg.Many(pg.Char()))
+	out, err := pg.Parse(rule, "Ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || string(out[0].Value) != "Ab" {
+		t.Errorf("expected a leaf Ab, got %v", out)
+	}
+}
+
+/*
+	A parser wrapping a combinator keeps doing what it adds to it once
+	composed, and is not run before parsing
+*/
+func TestDescribeWrapper(t *testing.T) {
+	runs := 0
+	tagged := func(in pg.State) ([]*pt.ParseTree, bool) {
+		runs += 1
+		out, ok := pg.Specify(9045, pg.Many1(pg.Number()))(in)
+		for _, tree := range out {
+			tree.ActualId = "tagged"
+		}
+		return out, ok
+	}
+	rule := pg.Concat(pg.Parser(tagged), pg.Many(pg.Concat(pg.Character(','), pg.Parser(tagged))))
+	if runs != 0 {
+		t.Errorf("expected the wrapper not to run while building, ran %d times", runs)
+	}
+	if kind := pg.Describe(tagged).Kind; kind != pg.EXPR_OPAQUE {
+		t.Errorf("expected the wrapper to be opaque, got kind %d", kind)
+	}
+	out, err := pg.Parse(rule, "1,23")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, tree := range out {
+		if tree.Type == 9045 {
+			ids = append(ids, tree.ActualId)
+		}
+	}
+	if actual := strings.Join(ids, " "); actual != "tagged tagged" || runs != 2 {
+		t.Errorf("expected both numbers tagged by 2 runs, got %q by %d", actual, runs)
+	}
+}
+
+/*
+	Characters that are not bytes never match, and all bytes are
+	any character but them
+*/
+func TestCharacterNotAByte(t *testing.T) {
+	for _, c := range []int{-1, 0x161} {
+		if out, err := pg.Parse(pg.Character(c), string([]byte{byte(c)})); err == nil {
+			t.Errorf("expected Character(%d) not to match, got %v", c, out)
+		}
+		out, err := pg.Parse(pg.Many(pg.AnyCharBut(c)), "a\x61\xc5")
+		if err != nil || len(out) != 1 || string(out[0].Value) != "a\x61\xc5" {
+			t.Errorf("expected AnyCharBut(%d) to match every byte, got %v, %v", c, out, err)
+		}
+	}
+	if actual := pg.Describe(pg.Character(0x161)).Format(nil); actual != "[]" {
+		t.Errorf("expected Character(0x161) to be an empty class, got %s", actual)
+	}
+	out, err := pg.Parse(pg.Character(0xff), "\xff")
+	if err != nil || len(out) != 1 || string(out[0].Value) != "\xff" {
+		t.Errorf("expected Character(0xff) to match its byte, got %v, %v", out, err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		rule     pg.Parser
+		expected string
+	}{
+		{pg.String("func"), `"func"`},
+		{pg.Empty(), `""`},
+		{pg.Concat(pg.String("a"), pg.Any(pg.Try(pg.Character('b')), pg.Character('c'))), `"a" ("b" / "c")`},
+		{pg.Any(pg.Try(pg.Concat(pg.Character('a'), pg.Character('b'))), pg.Character('c')), `"a" "b" / "c"`},
+		{pg.Many(pg.Concat(pg.Character('a'), pg.Character('b'))), `("a" "b")*`},
+		{pg.Many1(pg.Optional(pg.Character('a'))), `("a"?)+`},
+		{pg.Not(pg.Many(pg.Character('a'))), `!"a"*`},
+		{pg.And(pg.Not(pg.Character('a'))), `&(!"a")`},
+		{pg.CharClass(false, pg.CharRange{'a', 'z'}, pg.CharRange{'-', '-'}, pg.CharRange{']', ']'}), `[a-z\-\]]`},
+		{pg.CharClass(true, pg.CharRange{'\n', '\n'}, pg.CharRange{0, 0}), `[^\n\000]`},
+		{pg.AnyChar(), `.`},
+		{pg.AnyCharBut('"'), `[^"]`},
+		{pg.String("\"\n"), `"\"\n"`},
+		{pg.Between(pg.Character('('), pg.Number(), pg.Character(')')), `"(" [0-9] ")"`},
+		{pg.Optional(pg.Skip(pg.Concat(pg.Character('a'), pg.Character('b')))), `("a" "b")?`},
+		{pg.Concat(pg.Label("key", pg.Many1(pg.Char())), pg.Character('=')), `key "="`},
+		{pg.Parser(upperCase), `<opaque>`},
+	}
+	for _, test := range tests {
+		if actual := pg.Describe(test.rule).Format(nil); actual != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, actual)
+		}
+	}
+}
+
+func TestWritePEG(t *testing.T) {
+	var out strings.Builder
+	rule := pg.Concat(pair(), pg.Many(pg.Concat(pg.Character(';'), pair())))
+	if err := pg.WritePEG(&out, pg.Describe(rule), nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Start <- pair (";" pair)*
+pair <- key "=" (number / word)
+key <- [a-zA-Z]+
+number <- [0-9]+
+word <- [a-zA-Z]+
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%sgot\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	types := pt.NodeTypes{9038: "DIGITS"}
+	digits := pg.Specify(9038, pg.Many1(pg.Number()))
+	if err := pg.WritePEG(&out, pg.Describe(digits), types); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "DIGITS <- [0-9]+\n"; out.String() != expected {
+		t.Errorf("expected\n%sgot\n%s", expected, out.String())
+	}
+}
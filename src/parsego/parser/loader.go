@@ -0,0 +1,290 @@
+package pg
+
+import (
+	"fmt"
+	"parsego/parsetree"
+	"strings"
+)
+
+/*
+	A set of named rules loaded from text, see LoadPEG, LoadEBNF and LoadABNF
+*/
+type Grammar struct {
+	Types    pt.NodeTypes
+	Names    []string
+	notation string
+	rules    map[string]*Expr
+	refs     map[string]*Expr
+	foldCase bool
+}
+
+/*
+	How a loaded rule shows in the trees
+*/
+const (
+	ruleInline = iota
+	ruleNode
+	ruleSkipped
+)
+
+func newGrammar(notation string) *Grammar {
+	return &Grammar{
+		Types:    pt.NodeTypes{TYPE_UNDEFINED: "?"},
+		notation: notation,
+		rules:    make(map[string]*Expr),
+		refs:     make(map[string]*Expr),
+		foldCase: notation == "abnf",
+	}
+}
+
+/*
+	The parser for a rule, nil if it is not defined
+*/
+func (self *Grammar) Rule(name string) Parser {
+	if _, ok := self.rules[self.key(name)]; !ok {
+		return nil
+	}
+	return Compile(self.ref(name))
+}
+
+/*
+	The parser for the first rule
+*/
+func (self *Grammar) Start() Parser {
+	if len(self.Names) == 0 {
+		return Empty()
+	}
+	return self.Rule(self.Names[0])
+}
+
+/*
+	Parses the whole input from the start rule
+*/
+func (self *Grammar) Parse(input string) ([]*pt.ParseTree, error) {
+	return Parse(self.Start(), input)
+}
+
+/*
+	Adds the rules defined by src, written in the notation the grammar was
+	loaded from, replacing the rules of the same names: the parsers returned
+	by Rule and Start use the new definitions from their next parse on,
+	and replaced rules keep their node types.
+	Nothing changes if src has errors.
+*/
+func (self *Grammar) Define(src string) error {
+	names := append([]string{}, self.Names...)
+	rules := make(map[string]*Expr)
+	for key, rule := range self.rules {
+		rules[key] = rule
+	}
+	types := len(self.Types)
+
+	var err error
+	switch self.notation {
+	case "abnf":
+		err = loadABNF(self, src)
+	case "ebnf":
+		err = loadEBNF(self, src)
+	default:
+		err = loadPEG(self, src)
+	}
+	if err != nil {
+		self.Names, self.rules = names, rules
+		for nodeType := types; nodeType < len(self.Types); nodeType += 1 {
+			delete(self.Types, nodeType)
+		}
+	}
+	for _, ref := range self.refs {
+		ref.target = nil
+	}
+	return err
+}
+
+/*
+	ABNF rule names are case insensitive
+*/
+func (self *Grammar) key(name string) string {
+	if self.foldCase {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+func (self *Grammar) defined(name string) bool {
+	_, ok := self.rules[self.key(name)]
+	return ok
+}
+
+/*
+	Rules refer to each other through an EXPR_REF per name,
+	so they can be defined in any order
+*/
+func (self *Grammar) ref(name string) *Expr {
+	key := self.key(name)
+	ref, ok := self.refs[key]
+	if !ok {
+		ref = &Expr{Kind: EXPR_REF, Text: name}
+		ref.resolve = func() *Expr {
+			return self.rules[key]
+		}
+		self.refs[key] = ref
+	}
+	return ref
+}
+
+/*
+	References are named after the definition, whatever their spelling
+*/
+func (self *Grammar) define(name string, body Parser, kind int) {
+	switch kind {
+	case ruleSkipped:
+		body = Skip(body)
+	case ruleNode:
+		body = specify(self.nodeType(name), body)
+	}
+	if !self.defined(name) {
+		self.Names = append(self.Names, name)
+	}
+	self.ref(name).Text = name
+	self.rules[self.key(name)] = Describe(body)
+}
+
+/*
+	The node type of a rule, new unless it is redefined
+*/
+func (self *Grammar) nodeType(name string) int {
+	for nodeType, typeName := range self.Types {
+		if nodeType != TYPE_UNDEFINED && self.key(typeName) == self.key(name) {
+			return nodeType
+		}
+	}
+	nodeType := len(self.Types)
+	self.Types[nodeType] = name
+	return nodeType
+}
+
+/*
+	The position tracking and error reporting shared by the loaders
+*/
+type textLoader struct {
+	notation   string
+	source     string
+	position   int
+	grammar    *Grammar
+	references map[string]int
+	defined    map[string]bool
+}
+
+func newTextLoader(notation string, src string, grammar *Grammar) textLoader {
+	return textLoader{
+		notation:   notation,
+		source:     src,
+		grammar:    grammar,
+		references: make(map[string]int),
+		defined:    make(map[string]bool),
+	}
+}
+
+func (self *textLoader) errorf(format string, args ...interface{}) error {
+	line := strings.Count(self.source[:self.position], "\n") + 1
+	column := self.position - strings.LastIndexByte(self.source[:self.position], '\n')
+	return fmt.Errorf("pg: %s %d:%d: %s", self.notation, line, column, fmt.Sprintf(format, args...))
+}
+
+func (self *textLoader) peek() byte {
+	if self.position >= len(self.source) {
+		return 0
+	}
+	return self.source[self.position]
+}
+
+func (self *textLoader) unexpected() error {
+	if self.position >= len(self.source) {
+		return self.errorf("unexpected end of grammar")
+	}
+	return self.errorf("unexpected %q", self.source[self.position])
+}
+
+/*
+	A use of the named rule found at start, which must be defined
+	by the end of the grammar
+*/
+func (self *textLoader) reference(name string, start int) Parser {
+	key := self.grammar.key(name)
+	if _, ok := self.references[key]; !ok {
+		self.references[key] = start
+	}
+	return Compile(self.grammar.ref(name))
+}
+
+/*
+	Defines a rule of the grammar, which the loaded text may define once
+*/
+func (self *textLoader) define(name string, body Parser, kind int) {
+	self.defined[self.grammar.key(name)] = true
+	self.grammar.define(name, body, kind)
+}
+
+func (self *textLoader) redefines(name string) bool {
+	return self.defined[self.grammar.key(name)]
+}
+
+func (self *textLoader) checkReferences() error {
+	if len(self.grammar.Names) == 0 {
+		return self.errorf("no rules")
+	}
+	undefined := ""
+	for key, position := range self.references {
+		if _, ok := self.grammar.rules[key]; !ok && (undefined == "" || position < self.references[undefined]) {
+			undefined = key
+		}
+	}
+	if undefined == "" {
+		return nil
+	}
+	self.position = self.references[undefined]
+	return self.errorf("undefined rule %s", self.grammar.refs[undefined].Text)
+}
+
+func sequence(items []Parser) Parser {
+	switch len(items) {
+	case 0:
+		return Empty()
+	case 1:
+		return items[0]
+	}
+	return Concat(items...)
+}
+
+func choice(alternatives []Parser) Parser {
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return TryAny(alternatives...)
+}
+
+/*
+	Matches from min to max times, any number of times above min
+	if max is negative
+*/
+func repeat(match Parser, min, max int) Parser {
+	items := []Parser{}
+	for i := 0; i < min; i += 1 {
+		items = append(items, match)
+	}
+	if max < 0 {
+		return sequence(append(items, Many(match)))
+	}
+	var tail Parser
+	for i := min; i < max; i += 1 {
+		if tail == nil {
+			tail = Optional(match)
+		} else {
+			tail = Optional(Concat(match, tail))
+		}
+	}
+	if tail != nil {
+		items = append(items, tail)
+	}
+	return sequence(items)
+}
@@ -0,0 +1,91 @@
+package pg_test
+
+import (
+	"parsego/parser"
+	"strings"
+	"testing"
+)
+
+func TestDefine(t *testing.T) {
+	grammar := pg.MustLoadPEG(`
+		List <- Item ("," Item)*
+		Item <- [0-9]+
+	`)
+	start := grammar.Start()
+	if err := grammar.Define(`Item <- [a-z]+`); err != nil {
+		t.Fatal(err)
+	}
+	out, err := pg.Parse(start, "a,b")
+	if err != nil || sexprs(out) != `(1 (2 "a") "," (2 "b"))` {
+		t.Errorf("expected the parser obtained before to use the new Item, got %s, %v", sexprs(out), err)
+	}
+	if len(grammar.Types) != 3 || grammar.Types[2] != "Item" {
+		t.Errorf("expected Item to keep its type, got %v", grammar.Types)
+	}
+
+	if err := grammar.Define(`
+		Pair <- Item "=" Item
+		List <- Pair ("," Pair)*
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if names := strings.Join(grammar.Names, " "); names != "List Item Pair" {
+		t.Errorf("expected new rules after the others, got %s", names)
+	}
+	out, err = pg.Parse(start, "a=b")
+	if err != nil || sexprs(out) != `(1 (3 (2 "a") "=" (2 "b")))` {
+		t.Errorf("expected a List of a Pair, got %s, %v", sexprs(out), err)
+	}
+}
+
+/*
+	A Define with errors changes nothing, whatever it defined before them
+*/
+func TestDefineRollback(t *testing.T) {
+	grammar := pg.MustLoadPEG(`
+		List <- Item ("," Item)*
+		Item <- [0-9]+
+	`)
+	start := grammar.Start()
+	pg.Parse(start, "1,2")
+	err := grammar.Define(`
+		Item  <- "x"
+		Other <- Missing
+	`)
+	if err == nil || err.Error() != "pg: peg 3:12: undefined rule Missing" {
+		t.Errorf("expected Missing to be undefined, got %v", err)
+	}
+	if names := strings.Join(grammar.Names, " "); names != "List Item" {
+		t.Errorf("expected the rules unchanged, got %s", names)
+	}
+	if len(grammar.Types) != 3 || grammar.Rule("Other") != nil {
+		t.Errorf("expected no Other rule and type, got %v", grammar.Types)
+	}
+	out, err := pg.Parse(start, "1,2")
+	if err != nil || sexprs(out) != `(1 (2 "1") "," (2 "2"))` {
+		t.Errorf("expected the old Item, got %s, %v", sexprs(out), err)
+	}
+	if _, err := pg.Parse(start, "x"); err == nil {
+		t.Error("expected the new Item to be dropped")
+	}
+}
+
+func TestDefineNotations(t *testing.T) {
+	abnf := pg.MustLoadABNF("list = item *(\",\" item)\nitem = 1*DIGIT\n")
+	if err := abnf.Define("ITEM = 1*ALPHA\n"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := abnf.Parse("a,b")
+	if err != nil || sexprs(out) != `(1 (2 "a") "," (2 "b"))` {
+		t.Errorf("expected ITEM to replace item, got %s, %v", sexprs(out), err)
+	}
+
+	ebnf := pg.MustLoadEBNF(`list = item, {",", item}; item = "1";`)
+	if err := ebnf.Define(`item = "2";`); err != nil {
+		t.Fatal(err)
+	}
+	out, err = ebnf.Parse("2,2")
+	if err != nil || sexprs(out) != `(1 (2 "2") "," (2 "2"))` {
+		t.Errorf("expected item to be replaced, got %s, %v", sexprs(out), err)
+	}
+}
@@ -3,7 +3,6 @@ package pg
 import (
 	"fmt"
 	"parsego/parsetree"
-	"regexp"
 )
 
 type State interface {
@@ -17,6 +16,24 @@ type State interface {
 	GetProbeCount() int
 }
 
+/*
+	Implemented by States handing out their input without copying it,
+	like ParseState and ReaderState: leaves and trace events hold
+	the slices returned, which should end at their capacity so that
+	appending to a value never writes over the input. The leaves parsed
+	from other States copy the bytes read.
+*/
+type Slicing interface {
+	GetSlice(start, end int) []byte
+}
+
+func slice(in State, start, end int) []byte {
+	if slicing, ok := in.(Slicing); ok {
+		return slicing.GetSlice(start, end)
+	}
+	return nil
+}
+
 type Parser func(in State) ([]*pt.ParseTree, bool)
 
 type Cache interface {
@@ -37,9 +54,31 @@ type ParseState struct {
 	position   int
 	lineCount  int
 	probeCount int
+	farthest   int
+	tracer     Tracer
+	depth      int
+	steps      int
+	budget     int
+	overBudget bool
+	aborted    *ParseError
+	arena      *Arena
 }
 
+/*
+	The step budget of new parse states, 0 for none.
+	See ParseState.SetStepBudget.
+*/
+var DefaultStepBudget = 0
+
 func (self *ParseState) Next() (int, bool) {
+	if self.budget > 0 && self.steps >= self.budget {
+		self.overBudget = true
+		return 0, false
+	}
+	self.steps += 1
+	if self.position > self.farthest {
+		self.farthest = self.position
+	}
 	if self.position >= len(self.input) {
 		return 0, false
 	}
@@ -81,11 +120,75 @@ func (self *ParseState) GetProbeCount() int {
 	return self.probeCount
 }
 
+func (self *ParseState) GetFarthestPosition() int {
+	return self.farthest
+}
+
+func (self *ParseState) GetSlice(start, end int) []byte {
+	if end > len(self.input) {
+		end = len(self.input)
+	}
+	if start > end {
+		start = end
+	}
+	return self.input[start:end:end]
+}
+
+func (self *ParseState) GetTracer() Tracer {
+	return self.tracer
+}
+
+func (self *ParseState) SetTracer(tracer Tracer) {
+	self.tracer = tracer
+}
+
+func (self *ParseState) GetDepth() int {
+	return self.depth
+}
+
+func (self *ParseState) SetDepth(depth int) {
+	self.depth = depth
+}
+
+/*
+	Aborts runaway parses: once Next has been called steps times,
+	input ends for every parser, failing the parse.
+	Parse reports ErrStepBudget in this case.
+*/
+func (self *ParseState) SetStepBudget(steps int) {
+	self.budget = steps
+}
+
+func (self *ParseState) GetSteps() int {
+	return self.steps
+}
+
+/*
+	Whether Next refused a step past the budget, a parse using
+	exactly its budget is not over it
+*/
+func (self *ParseState) IsOverBudget() bool {
+	return self.overBudget
+}
+
+/*
+	Allocates the trees of the parses from arena, nil for the heap.
+	See Arena.
+*/
+func (self *ParseState) SetArena(arena *Arena) {
+	self.arena = arena
+}
+
+func (self *ParseState) GetArena() *Arena {
+	return self.arena
+}
+
 func InitParser() *ParseState {
 	state := new(ParseState)
 	state.SetPosition(0)
 	state.SetLineCount(1)
 	state.SetInput("")
+	state.SetStepBudget(DefaultStepBudget)
 	return state
 }
 
@@ -115,42 +218,18 @@ func initParserCache() Cache {
 
 /*
 	Matches *
+	Stops after an iteration matching without consuming input,
+	that would otherwise repeat forever
 */
 func Many(match Parser) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		nodes := []*pt.ParseTree{}
-		for {
-			out, ok := Try(match)(in)
-			if !ok {
-				break
-			}
-			nodes = concat(nodes, out)
-		}
-		return nodes, true
-	}
+	return Compile(&Expr{Kind: EXPR_STAR, Children: []*Expr{Describe(match)}})
 }
 
 /*
 	Matches +
 */
 func Many1(match Parser) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		nodes := []*pt.ParseTree{}
-		out, ok := match(in)
-		if !ok {
-			return nil, false
-		}
-		nodes = concat(nodes, out)
-
-		for {
-			out, ok := Try(match)(in)
-			if !ok {
-				break
-			}
-			nodes = concat(nodes, out)
-		}
-		return nodes, true
-	}
+	return Compile(&Expr{Kind: EXPR_PLUS, Children: []*Expr{Describe(match)}})
 }
 
 /*
@@ -158,15 +237,7 @@ func Many1(match Parser) Parser {
 	Wrap parsers in Try(...) calls to preserve state
 */
 func Any(matches ...Parser) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		for _, match := range matches {
-			out, ok := match(in)
-			if ok {
-				return out, true
-			}
-		}
-		return nil, false
-	}
+	return Compile(&Expr{Kind: EXPR_CHOICE, Children: describeAll(matches)})
 }
 
 /*
@@ -184,130 +255,68 @@ func TryAny(matches ...Parser) Parser {
 	Matches concatenation
 */
 func Concat(matches ...Parser) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		nodes := []*pt.ParseTree{}
-		for _, match := range matches {
-			out, ok := match(in)
-			if !ok {
-				return nil, false
-			}
-			nodes = concat(nodes, out)
-		}
-		return nodes, true
-	}
+	return Compile(&Expr{Kind: EXPR_SEQ, Children: describeAll(matches)})
 }
 
 /*
 	Tries to match, preserving initial state in case of fail
 */
 func Try(match Parser) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		initialPosition := in.GetPosition()
-		initialLineCount := in.GetLineCount()
-		out, ok := match(in)
-		if !ok {
-			in.SetPosition(initialPosition)
-			in.SetLineCount(initialLineCount)
-		}
-		return out, ok
-	}
+	return Compile(&Expr{Kind: EXPR_TRY, Children: []*Expr{Describe(match)}})
 }
 
 /*
-	Matches a single character
+	Matches a single character, a byte: use String for other runes.
+	A c that is not a byte never matches, as the input is read by bytes.
 */
 func Character(c int) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		target, ok := in.Next()
-		if ok && c == int(target) {
-			node := new(pt.ParseTree)
-			node.Value = []byte{byte(c)}
-			return []*pt.ParseTree{node}, true
-		}
-		return nil, false
+	if !isByte(c) {
+		return Compile(&Expr{Kind: EXPR_CLASS})
 	}
+	return Compile(&Expr{Kind: EXPR_TERMINAL, Text: string([]byte{byte(c)})})
+}
+
+func isByte(c int) bool {
+	return c >= 0 && c <= 0xff
 }
 
 /*
 	Matches [a-zA-Z]
 */
 func Char() Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		target, ok := in.Next()
-		if ok {
-			match, _ := regexp.Match("[a-zA-Z]", []byte{byte(target)})
-			if match {
-				node := new(pt.ParseTree)
-				node.Value = []byte{byte(target)}
-				return []*pt.ParseTree{node}, true
-			}
-		}
-		return nil, false
-	}
+	return Compile(&Expr{Kind: EXPR_CLASS, Ranges: []CharRange{{'a', 'z'}, {'A', 'Z'}}})
 }
 
 /*
-	Matches [^c]
+	Matches [^c], c being a byte: any byte when c is not one
 */
 func AnyCharBut(c int) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		target, ok := in.Next()
-		if ok {
-			match, _ := regexp.Match(fmt.Sprintf("[^%c]", c), []byte{byte(target)})
-			if match {
-				node := new(pt.ParseTree)
-				node.Value = []byte{byte(target)}
-				return []*pt.ParseTree{node}, true
-			}
-		}
-		return nil, false
+	expr := &Expr{Kind: EXPR_CLASS, Negated: true}
+	if isByte(c) {
+		expr.Ranges = []CharRange{{byte(c), byte(c)}}
 	}
+	return Compile(expr)
 }
 
 /*
 	Matches [0-9]
 */
 func Number() Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		target, ok := in.Next()
-		if ok {
-			match, _ := regexp.Match("[0-9]", []byte{byte(target)})
-			if match {
-				node := new(pt.ParseTree)
-				node.Value = []byte{byte(target)}
-				return []*pt.ParseTree{node}, true
-			}
-		}
-		return nil, false
-	}
+	return Compile(&Expr{Kind: EXPR_CLASS, Ranges: []CharRange{{'0', '9'}}})
 }
 
 /*
 	Matches [\s]
 */
 func Whitespace() Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		target, ok := in.Next()
-		if ok {
-			match, _ := regexp.Match("\\s", []byte{byte(target)})
-			if match {
-				node := new(pt.ParseTree)
-				node.Value = []byte{byte(target)}
-				return []*pt.ParseTree{node}, true
-			}
-		}
-		return nil, false
-	}
+	return Compile(&Expr{Kind: EXPR_CLASS, Ranges: []CharRange{{'\t', '\n'}, {'\f', '\r'}, {' ', ' '}}})
 }
 
 /*
 	Skips matching
 */
 func Skip(match Parser) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		_, ok := match(in)
-		return nil, ok
-	}
+	return Compile(&Expr{Kind: EXPR_SKIP, Children: []*Expr{Describe(match)}})
 }
 
 /*
@@ -338,21 +347,7 @@ func Trim(match Parser) Parser {
 	Matches between two parsers
 */
 func Between(left, match, right Parser) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		_, okl := left(in)
-		if !okl {
-			return nil, false
-		}
-
-		out, ok := match(in)
-
-		_, okr := right(in)
-		if !okr {
-			return nil, false
-		}
-
-		return out, ok
-	}
+	return Compile(&Expr{Kind: EXPR_BETWEEN, Children: describeAll([]Parser{left, match, right})})
 }
 
 /*
@@ -389,31 +384,49 @@ func Semi() Parser {
 	Matches exact string
 */
 func String(s string) Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		matched := make([]byte, 0)
-		node := new(pt.ParseTree)
-		for _, c := range s {
-			out, ok := Character(int(c))(in)
-			if !ok {
-				node.Value = matched
-				return []*pt.ParseTree{node}, false
-			}
-			if out != nil {
-				matched = append(matched, out[0].Value[0])
-			}
-		}
-		node.Value = matched
-		return []*pt.ParseTree{node}, true
-	}
+	return Compile(&Expr{Kind: EXPR_TERMINAL, Text: s})
 }
 
 /*
 	Matches emptiness
 */
 func Empty() Parser {
-	return func(in State) ([]*pt.ParseTree, bool) {
-		return nil, true
-	}
+	return Compile(&Expr{Kind: EXPR_EMPTY})
+}
+
+/*
+	Matches ?
+*/
+func Optional(match Parser) Parser {
+	return Compile(&Expr{Kind: EXPR_OPTIONAL, Children: []*Expr{Describe(match)}})
+}
+
+/*
+	Matches &, without consuming input
+*/
+func And(match Parser) Parser {
+	return Compile(&Expr{Kind: EXPR_AND, Children: []*Expr{Describe(match)}})
+}
+
+/*
+	Matches !, without consuming input
+*/
+func Not(match Parser) Parser {
+	return Compile(&Expr{Kind: EXPR_NOT, Children: []*Expr{Describe(match)}})
+}
+
+/*
+	Matches .
+*/
+func AnyChar() Parser {
+	return CharClass(true)
+}
+
+/*
+	Matches [ranges], or [^ranges] if negated
+*/
+func CharClass(negated bool, ranges ...CharRange) Parser {
+	return Compile(&Expr{Kind: EXPR_CLASS, Ranges: ranges, Negated: negated})
 }
 
 /*
@@ -427,31 +440,15 @@ func Specify(nodeType int, match Parser) Parser {
 	specId := fmt.Sprintf("_SPEC_%d", nodeType)
 	cached := cache.Get(specId)
 	if cached == nil {
-		cache.Set(specId, func(in State) ([]*pt.ParseTree, bool) {
-			pos := new(pt.InputPosition)
-			pos.StartPosition = in.GetPosition()
-			pos.StartLine = in.GetLineCount()
-			out, ok := match(in)
-			if !ok {
-				return nil, false
-			}
-			pos.EndPosition = in.GetPosition()
-			pos.EndLine = in.GetLineCount()
-
-			nodes := []*pt.ParseTree{new(pt.ParseTree)}
-			nodes[0].Type = nodeType
-			nodes[0].Position = *pos
-			if out != nil && len(out) > 0 && out[0].Type == TYPE_UNDEFINED {
-				nodes[0].Value = out[0].Value
-			} else {
-				appendChildren(nodes[0], out)
-			}
-			return nodes, true
-		})
+		cache.Set(specId, specify(nodeType, match))
 	}
 	return cache.Get(specId)
 }
 
+func specify(nodeType int, match Parser) Parser {
+	return Compile(&Expr{Kind: EXPR_NODE, NodeType: nodeType, Children: []*Expr{Describe(match)}})
+}
+
 /*
 	Helper for recursive rules
 */
@@ -459,46 +456,55 @@ func Recursive(id string, matchMaker func() Parser) Parser {
 	recId := "_REC_" + id
 	cachedRec := cache.Get(recId)
 	if cachedRec == nil {
-		cache.Set(recId, func(in State) ([]*pt.ParseTree, bool) {
+		expr := &Expr{Kind: EXPR_REF, Text: id}
+		expr.resolve = func() *Expr {
 			cached := cache.Get(id)
 			if cached == nil {
 				cache.Set(id, matchMaker())
 			}
-			return cache.Get(id)(in)
-		})
+			if cached := cache.Get(id); cached != nil {
+				return Describe(cached)
+			}
+			return nil
+		}
+		cache.Set(recId, Compile(expr))
 	}
 	return cache.Get(recId)
 }
 
+/*
+	Names a rule, for tracing
+*/
+func Label(name string, match Parser) Parser {
+	return Compile(&Expr{Kind: EXPR_LABEL, Text: name, Children: []*Expr{Describe(match)}})
+}
+
 /*
 	Utility
 */
 
+/*
+	Leaf values are slices of the input capped at their end, so the
+	first merge copies them to a buffer of their own, which the next
+	merges grow in place: a value merged from n leaves takes O(n).
+	A value with room past its end is taken as owned by its tree.
+*/
 func concatBytes(old1, old2 []byte) []byte {
-	newslice := make([]byte, len(old1)+len(old2))
-	copy(newslice, old1)
-	copy(newslice[len(old1):], old2)
-	return newslice
+	if len(old1) == 0 {
+		return old2
+	}
+	return append(old1, old2...)
 }
 
-func concat(a, b []*pt.ParseTree) []*pt.ParseTree {
+func concat(arena *Arena, a, b []*pt.ParseTree) []*pt.ParseTree {
 	if b == nil {
 		return a
 	}
 
-	if len(a) == 1 && len(b) == 1 && a[0].Type == TYPE_UNDEFINED {
+	if len(a) == 1 && len(b) == 1 && a[0].Type == TYPE_UNDEFINED && b[0].Type == TYPE_UNDEFINED {
 		a[0].Value = concatBytes(a[0].Value, b[0].Value)
 		return a
 	}
 
-	for _, e := range b {
-		a = append(a, e)
-	}
-	return a
-}
-
-func appendChildren(node *pt.ParseTree, children []*pt.ParseTree) {
-	for _, child := range children {
-		node.Children = append(node.Children, child)
-	}
+	return arena.appendList(a, b)
 }
@@ -0,0 +1,80 @@
+package pg_test
+
+import (
+	"parsego/parser"
+	"parsego/pgtest"
+	"strings"
+	"testing"
+)
+
+/*
+	Every primitive matching a sample, repeated by Many
+	over inputs of increasing size
+*/
+var primitives = []struct {
+	name   string
+	rule   func() pg.Parser
+	sample string
+}{
+	{"Character", func() pg.Parser { return pg.Character('a') }, "a"},
+	{"String", func() pg.Parser { return pg.String("abc") }, "abc"},
+	{"Char", pg.Char, "x"},
+	{"Number", pg.Number, "7"},
+	{"Whitespace", pg.Whitespace, " "},
+	{"AnyChar", pg.AnyChar, "."},
+	{"CharClass", func() pg.Parser { return pg.CharClass(false, pg.CharRange{'a', 'f'}) }, "c"},
+	{"Concat", func() pg.Parser { return pg.Concat(pg.Char(), pg.Number()) }, "a1"},
+	{"Any", func() pg.Parser { return pg.Any(pg.Try(pg.Number()), pg.Char()) }, "a"},
+	{"TryAny", func() pg.Parser {
+		return pg.TryAny(pg.Concat(pg.Char(), pg.Number()), pg.Concat(pg.Char(), pg.Char()))
+	}, "ab"},
+	{"Try", func() pg.Parser { return pg.Try(pg.Char()) }, "a"},
+	{"Optional", func() pg.Parser { return pg.Concat(pg.Optional(pg.Number()), pg.Char()) }, "a"},
+	{"Many1", func() pg.Parser { return pg.Concat(pg.Many1(pg.Char()), pg.Whitespace()) }, "abc "},
+	{"And", func() pg.Parser { return pg.Concat(pg.And(pg.Char()), pg.Char()) }, "a"},
+	{"Not", func() pg.Parser { return pg.Concat(pg.Not(pg.Number()), pg.Char()) }, "a"},
+	{"Skip", func() pg.Parser { return pg.Skip(pg.Char()) }, "a"},
+	{"Trim", func() pg.Parser { return pg.Trim(pg.Number()) }, " 1 "},
+	{"Parens", func() pg.Parser { return pg.Parens(pg.Number()) }, "( 1 )"},
+	{"Specify", func() pg.Parser { return pg.Specify(1, pg.Char()) }, "a"},
+	{"Recursive", nested, "((1))"},
+}
+
+func nested() pg.Parser {
+	return pg.Recursive("nested", func() pg.Parser {
+		return pg.Any(pg.Try(pg.Number()), pg.Parens(nested()))
+	})
+}
+
+func BenchmarkPrimitives(b *testing.B) {
+	for _, primitive := range primitives {
+		rule := pg.Many(primitive.rule())
+		sample := primitive.sample
+		generate := func(size int) string {
+			return strings.Repeat(sample, size/len(sample))
+		}
+		pg.Parse(rule, sample)
+		b.Run(primitive.name, func(b *testing.B) {
+			pgtest.Scaling(b, rule, generate, 1<<10, 16<<10, 256<<10)
+		})
+	}
+}
+
+/*
+	Reading the input alone, the floor of every parse
+*/
+func BenchmarkNext(b *testing.B) {
+	input := strings.Repeat("a\n", 32<<10)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i += 1 {
+		state := pg.InitParser()
+		state.SetInput(input)
+		for {
+			if _, ok := state.Next(); !ok {
+				break
+			}
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(len(input)), "ns/byte")
+}
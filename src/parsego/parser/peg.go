@@ -0,0 +1,385 @@
+package pg
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+	Loads a grammar written in PEG notation, one rule per definition:
+
+	# comments run to the end of the line
+	Sum        <- Product (_ [+-] _ Product)*
+	Product    <- Number / "(" _ Sum _ ")"
+	Number     <- digit+
+	digit      <- [0-9]
+	_          <- [ \t\n]*
+
+	with sequences, ordered choice /, the * + ? suffixes, the & and !
+	predicates, "literals", 'literals', [classes], [^negated classes],
+	. for any character and both <- and ← as arrows.
+	Rules starting with an uppercase letter build nodes of their own type,
+	numbered in order of definition in Types. Rules starting with _ are
+	skipped and the others pass on what they match, like unnamed expressions.
+	The first rule is the start rule.
+*/
+func LoadPEG(src string) (*Grammar, error) {
+	grammar := newGrammar("peg")
+	if err := loadPEG(grammar, src); err != nil {
+		return nil, err
+	}
+	return grammar, nil
+}
+
+/*
+	Loads a grammar, panicking on errors
+*/
+func MustLoadPEG(src string) *Grammar {
+	grammar, err := LoadPEG(src)
+	if err != nil {
+		panic(err)
+	}
+	return grammar
+}
+
+func loadPEG(grammar *Grammar, src string) error {
+	loader := &pegLoader{newTextLoader("peg", src, grammar)}
+	if err := loader.parseGrammar(); err != nil {
+		return err
+	}
+	return loader.checkReferences()
+}
+
+func pegRuleKind(name string) int {
+	switch first := name[0]; {
+	case first == '_':
+		return ruleSkipped
+	case first >= 'A' && first <= 'Z':
+		return ruleNode
+	}
+	return ruleInline
+}
+
+/*
+	PEG parsing
+*/
+
+type pegLoader struct {
+	textLoader
+}
+
+func (self *pegLoader) skipSpacing() {
+	for self.position < len(self.source) {
+		switch self.source[self.position] {
+		case ' ', '\t', '\r', '\n':
+			self.position += 1
+		case '#':
+			for self.position < len(self.source) && self.source[self.position] != '\n' {
+				self.position += 1
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
+
+func (self *pegLoader) parseIdentifier() string {
+	start := self.position
+	if !isIdentStart(self.peek()) {
+		return ""
+	}
+	for isIdentChar(self.peek()) {
+		self.position += 1
+	}
+	name := self.source[start:self.position]
+	self.skipSpacing()
+	return name
+}
+
+func (self *pegLoader) parseArrow() bool {
+	for _, arrow := range []string{"<-", "←"} {
+		if strings.HasPrefix(self.source[self.position:], arrow) {
+			self.position += len(arrow)
+			self.skipSpacing()
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	Reports whether a definition starts here, ending the current one
+*/
+func (self *pegLoader) atDefinition() bool {
+	start := self.position
+	defer func() { self.position = start }()
+	return self.parseIdentifier() != "" && self.parseArrow()
+}
+
+func (self *pegLoader) parseGrammar() error {
+	self.skipSpacing()
+	for self.position < len(self.source) {
+		start := self.position
+		name := self.parseIdentifier()
+		if name == "" {
+			return self.unexpected()
+		}
+		if !self.parseArrow() {
+			return self.errorf("expected <- after %s", name)
+		}
+		if self.redefines(name) {
+			self.position = start
+			return self.errorf("rule %s redefined", name)
+		}
+		body, err := self.parseExpression()
+		if err != nil {
+			return err
+		}
+		if self.position < len(self.source) && !self.atDefinition() {
+			return self.unexpected()
+		}
+		self.define(name, body, pegRuleKind(name))
+	}
+	return nil
+}
+
+func (self *pegLoader) parseExpression() (Parser, error) {
+	alternatives := []Parser{}
+	for {
+		sequence, err := self.parseSequence()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, sequence)
+		if self.peek() != '/' {
+			break
+		}
+		self.position += 1
+		self.skipSpacing()
+	}
+	return choice(alternatives), nil
+}
+
+func (self *pegLoader) parseSequence() (Parser, error) {
+	items := []Parser{}
+	for {
+		switch self.peek() {
+		case 0, '/', ')':
+		default:
+			if !self.atDefinition() {
+				item, err := self.parsePrefix()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				continue
+			}
+		}
+		break
+	}
+	return sequence(items), nil
+}
+
+func (self *pegLoader) parsePrefix() (Parser, error) {
+	switch prefix := self.peek(); prefix {
+	case '&', '!':
+		self.position += 1
+		self.skipSpacing()
+		match, err := self.parseSuffix()
+		if err != nil {
+			return nil, err
+		}
+		if prefix == '&' {
+			return And(match), nil
+		}
+		return Not(match), nil
+	}
+	return self.parseSuffix()
+}
+
+func (self *pegLoader) parseSuffix() (Parser, error) {
+	match, err := self.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch self.peek() {
+		case '*':
+			match = Many(match)
+		case '+':
+			match = Many1(match)
+		case '?':
+			match = Optional(match)
+		default:
+			return match, nil
+		}
+		self.position += 1
+		self.skipSpacing()
+	}
+}
+
+func (self *pegLoader) parsePrimary() (Parser, error) {
+	switch c := self.peek(); {
+	case isIdentStart(c):
+		start := self.position
+		return self.reference(self.parseIdentifier(), start), nil
+	case c == '(':
+		self.position += 1
+		self.skipSpacing()
+		match, err := self.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if self.peek() != ')' {
+			return nil, self.unexpected()
+		}
+		self.position += 1
+		self.skipSpacing()
+		return match, nil
+	case c == '"' || c == '\'':
+		return self.parseLiteral()
+	case c == '[':
+		return self.parseClass()
+	case c == '.':
+		self.position += 1
+		self.skipSpacing()
+		return AnyChar(), nil
+	}
+	return nil, self.unexpected()
+}
+
+func (self *pegLoader) parseLiteral() (Parser, error) {
+	quote := self.source[self.position]
+	self.position += 1
+	var text strings.Builder
+	for self.peek() != quote {
+		if self.position >= len(self.source) {
+			return nil, self.errorf("unterminated literal")
+		}
+		c, err := self.parseChar()
+		if err != nil {
+			return nil, err
+		}
+		text.WriteString(c)
+	}
+	self.position += 1
+	self.skipSpacing()
+	if text.Len() == 0 {
+		return Empty(), nil
+	}
+	return String(text.String()), nil
+}
+
+func (self *pegLoader) parseClass() (Parser, error) {
+	self.position += 1
+	negated := false
+	if self.peek() == '^' {
+		negated = true
+		self.position += 1
+	}
+	ranges := []CharRange{}
+	for self.peek() != ']' {
+		if self.position >= len(self.source) {
+			return nil, self.errorf("unterminated class")
+		}
+		low, err := self.parseClassChar()
+		if err != nil {
+			return nil, err
+		}
+		high := low
+		if self.peek() == '-' && self.position+1 < len(self.source) && self.source[self.position+1] != ']' {
+			self.position += 1
+			if high, err = self.parseClassChar(); err != nil {
+				return nil, err
+			}
+			if high < low {
+				return nil, self.errorf("invalid range %q-%q", low, high)
+			}
+		}
+		ranges = append(ranges, CharRange{low, high})
+	}
+	self.position += 1
+	self.skipSpacing()
+	return CharClass(negated, ranges...), nil
+}
+
+func (self *pegLoader) parseClassChar() (byte, error) {
+	start := self.position
+	c, err := self.parseChar()
+	if err != nil {
+		return 0, err
+	}
+	if len(c) != 1 {
+		self.position = start
+		return 0, self.errorf("classes only match single bytes")
+	}
+	return c[0], nil
+}
+
+/*
+	A character of a literal or class, possibly escaped:
+	\n \r \t \f \v, \ before any punctuation, octal \0-\377, \xHH and \uHHHH
+*/
+func (self *pegLoader) parseChar() (string, error) {
+	c := self.source[self.position]
+	self.position += 1
+	if c != '\\' {
+		return string([]byte{c}), nil
+	}
+	if self.position >= len(self.source) {
+		return "", self.unexpected()
+	}
+	c = self.source[self.position]
+	self.position += 1
+	switch {
+	case c == 'n':
+		return "\n", nil
+	case c == 'r':
+		return "\r", nil
+	case c == 't':
+		return "\t", nil
+	case c == 'f':
+		return "\f", nil
+	case c == 'v':
+		return "\v", nil
+	case c >= '0' && c <= '7':
+		start := self.position - 1
+		for self.position < start+3 && self.peek() >= '0' && self.peek() <= '7' {
+			self.position += 1
+		}
+		value, err := strconv.ParseUint(self.source[start:self.position], 8, 8)
+		if err != nil {
+			return "", self.errorf("invalid escape \\%s", self.source[start:self.position])
+		}
+		return string([]byte{byte(value)}), nil
+	case c == 'x' || c == 'u':
+		digits := 2
+		if c == 'u' {
+			digits = 4
+		}
+		if self.position+digits > len(self.source) {
+			return "", self.errorf("invalid escape \\%c", c)
+		}
+		value, err := strconv.ParseUint(self.source[self.position:self.position+digits], 16, 32)
+		if err != nil {
+			return "", self.errorf("invalid escape \\%c%s", c, self.source[self.position:self.position+digits])
+		}
+		self.position += digits
+		if c == 'x' {
+			return string([]byte{byte(value)}), nil
+		}
+		return string(rune(value)), nil
+	case c < 0x80 && !isIdentChar(c):
+		return string([]byte{c}), nil
+	}
+	self.position -= 1
+	return "", self.errorf("invalid escape \\%c", c)
+}
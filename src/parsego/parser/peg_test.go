@@ -0,0 +1,126 @@
+package pg_test
+
+import (
+	"parsego/parser"
+	"strings"
+	"testing"
+)
+
+const sumGrammar = `
+	# comments run to the end of the line
+	Sum        <- Product (_ [+-] _ Product)*
+	Product    <- Number / "(" _ Sum _ ")"
+	Number     <- digit+
+	digit      <- [0-9]
+	_          <- [ \t\n]*
+`
+
+func TestLoadPEG(t *testing.T) {
+	grammar := pg.MustLoadPEG(sumGrammar)
+	if names := strings.Join(grammar.Names, " "); names != "Sum Product Number digit _" {
+		t.Errorf("expected the rules in order of definition, got %s", names)
+	}
+	for nodeType, name := range []string{"?", "Sum", "Product", "Number"} {
+		if grammar.Types[nodeType] != name {
+			t.Errorf("expected type %d to be %s, got %s", nodeType, name, grammar.Types[nodeType])
+		}
+	}
+	if len(grammar.Types) != 4 {
+		t.Errorf("expected types for the uppercase rules only, got %v", grammar.Types)
+	}
+
+	out, err := grammar.Parse("1 + (20-3)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `(1 (2 (3 "1")) "+" (2 "(" (1 (2 (3 "20")) "-" (2 (3 "3"))) ")"))`
+	if actual := sexprs(out); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	if _, err := grammar.Parse("1 +"); err == nil || err.Error() != "1:4: unexpected end of input" {
+		t.Errorf("expected a failure at the end of input, got %v", err)
+	}
+
+	out, err = pg.Parse(grammar.Rule("Number"), "42")
+	if err != nil || sexprs(out) != `(3 "42")` {
+		t.Errorf("expected a Number 42, got %s, %v", sexprs(out), err)
+	}
+	if grammar.Rule("Missing") != nil {
+		t.Error("expected no parser for an undefined rule")
+	}
+}
+
+func TestLoadPEGExpressions(t *testing.T) {
+	tests := []struct {
+		name     string
+		grammar  string
+		input    string
+		expected string
+	}{
+		{"single quotes", `S <- 'a' "b"`, "ab", `(1 "ab")`},
+		{"escapes", `S <- "\n\t\"\\\101\x42\u00e9"`, "\n\t\"\\ABé", `(1 "\n\t\"\\ABé")`},
+		{"empty literal", `S <- "" "a"`, "a", `(1 "a")`},
+		{"class", `S <- [a-c\]\-]+`, "ab]-c", `(1 "ab]-c")`},
+		{"negated class", `S <- [^a]`, "b", `(1 "b")`},
+		{"trailing dash", `S <- [a-]+`, "a-", `(1 "a-")`},
+		{"any", `S <- . .`, "é", `(1 "é")`},
+		{"optional", `S <- "a"? "b"`, "b", `(1 "b")`},
+		{"and", `S <- &"a" .`, "a", `(1 "a")`},
+		{"not", `S <- (!";" .)* ";"`, "ab;", `(1 "ab;")`},
+		{"ordered choice", `S <- "a" "b" / "a"`, "a", `(1 "a")`},
+		{"arrow", `S ← "a"`, "a", `(1 "a")`},
+		{"inline rule", `S <- x x
+			x <- "a"`, "aa", `(1 "aa")`},
+		{"skipped rule", `S <- "a" _ "b"
+			_ <- " "*`, "a  b", `(1 "ab")`},
+		{"node rules", `S <- "(" A ")"
+			A <- "a"`, "(a)", `(1 "(" (2 "a") ")")`},
+		{"forward references", `S <- A B
+			B <- "b"
+			A <- "a"`, "ab", `(1 (3 "a") (2 "b"))`},
+	}
+	for _, test := range tests {
+		grammar, err := pg.LoadPEG(test.grammar)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		out, err := grammar.Parse(test.input)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		} else if actual := sexprs(out); actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestLoadPEGErrors(t *testing.T) {
+	tests := []struct {
+		grammar  string
+		expected string
+	}{
+		{"", "pg: peg 1:1: no rules"},
+		{"# only comments\n", "pg: peg 2:1: no rules"},
+		{"S <- A", "pg: peg 1:6: undefined rule A"},
+		{"S", "pg: peg 1:2: expected <- after S"},
+		{"<- \"a\"", "pg: peg 1:1: unexpected '<'"},
+		{"S <- \"a\"\nS <- \"b\"", "pg: peg 2:1: rule S redefined"},
+		{"S <- (\"a\"", "pg: peg 1:10: unexpected end of grammar"},
+		{"S <- \"a\" )", "pg: peg 1:10: unexpected ')'"},
+		{"S <- \"a", "pg: peg 1:8: unterminated literal"},
+		{"S <- [a", "pg: peg 1:8: unterminated class"},
+		{"S <- [z-a]", "pg: peg 1:10: invalid range 'z'-'a'"},
+		{"S <- [\\u00e9]", "pg: peg 1:7: classes only match single bytes"},
+		{"S <- \"\\q\"", "pg: peg 1:8: invalid escape \\q"},
+		{"S <- \"\\xZZ\"", "pg: peg 1:9: invalid escape \\xZZ"},
+		{"S <- \"\\777\"", "pg: peg 1:11: invalid escape \\777"},
+	}
+	for _, test := range tests {
+		grammar, err := pg.LoadPEG(test.grammar)
+		if err == nil {
+			t.Errorf("%q: expected %s, got rules %v", test.grammar, test.expected, grammar.Names)
+		} else if err.Error() != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.grammar, test.expected, err)
+		}
+	}
+}
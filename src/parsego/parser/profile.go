@@ -0,0 +1,275 @@
+package pg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"parsego/parsetree"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+/*
+	Totals for a named rule (Specify, Recursive or Label).
+	Total time includes nested named rules, Self time does not.
+	Backtracked counts the bytes read by failed calls before giving up.
+	MemoHits counts calls at a position the rule had already been tried
+	at, that is, the calls a memo table would have answered.
+*/
+type RuleProfile struct {
+	Name        string
+	Calls       int
+	Successes   int
+	Total       time.Duration
+	Self        time.Duration
+	Consumed    int
+	Backtracked int
+	MemoHits    int
+}
+
+/*
+	A Tracer attributing parse time and work to named rules:
+	in.SetTracer(profiler)
+*/
+type Profiler struct {
+	types   pt.NodeTypes
+	rules   map[string]*RuleProfile
+	stack   []profileFrame
+	tried   map[profileKey]bool
+	samples map[string]*profileSample
+	started time.Time
+}
+
+type profileFrame struct {
+	name     string
+	start    time.Time
+	children time.Duration
+}
+
+type profileKey struct {
+	name     string
+	position int
+}
+
+type profileSample struct {
+	stack       []string
+	calls       int64
+	self        int64
+	backtracked int64
+}
+
+func NewProfiler(types pt.NodeTypes) *Profiler {
+	profiler := new(Profiler)
+	profiler.types = types
+	profiler.Reset()
+	return profiler
+}
+
+/*
+	Discards all collected data
+*/
+func (self *Profiler) Reset() {
+	self.rules = make(map[string]*RuleProfile)
+	self.stack = nil
+	self.tried = make(map[profileKey]bool)
+	self.samples = make(map[string]*profileSample)
+	self.started = time.Now()
+}
+
+func (self *Profiler) Trace(event *TraceEvent) {
+	now := time.Now()
+	name := event.Name(self.types)
+	switch event.Kind {
+	case TRACE_ENTER:
+		self.stack = append(self.stack, profileFrame{name: name, start: now})
+		return
+	case TRACE_SUCCESS, TRACE_FAILURE:
+	default:
+		return
+	}
+	if len(self.stack) == 0 {
+		return
+	}
+	frame := self.stack[len(self.stack)-1]
+	self.stack = self.stack[:len(self.stack)-1]
+	total := now.Sub(frame.start)
+	if len(self.stack) > 0 {
+		self.stack[len(self.stack)-1].children += total
+	}
+
+	rule := self.rules[name]
+	if rule == nil {
+		rule = &RuleProfile{Name: name}
+		self.rules[name] = rule
+	}
+	rule.Calls += 1
+	rule.Total += total
+	rule.Self += total - frame.children
+	key := profileKey{name, event.Start}
+	if self.tried[key] {
+		rule.MemoHits += 1
+	}
+	self.tried[key] = true
+	backtracked := 0
+	if event.Kind == TRACE_SUCCESS {
+		rule.Successes += 1
+		rule.Consumed += event.Position - event.Start
+	} else {
+		backtracked = event.Position - event.Start
+		rule.Backtracked += backtracked
+	}
+
+	stack := []string{name}
+	for i := len(self.stack) - 1; i >= 0; i -= 1 {
+		stack = append(stack, self.stack[i].name)
+	}
+	sampleKey := strings.Join(stack, "\x00")
+	sample := self.samples[sampleKey]
+	if sample == nil {
+		sample = &profileSample{stack: stack}
+		self.samples[sampleKey] = sample
+	}
+	sample.calls += 1
+	sample.self += int64(total - frame.children)
+	sample.backtracked += int64(backtracked)
+}
+
+/*
+	Returns the profiled rules, by decreasing self time
+*/
+func (self *Profiler) Rules() []*RuleProfile {
+	rules := []*RuleProfile{}
+	for _, rule := range self.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Self != rules[j].Self {
+			return rules[i].Self > rules[j].Self
+		}
+		return rules[i].Name < rules[j].Name
+	})
+	return rules
+}
+
+/*
+	Writes a table of the profiled rules, by decreasing self time
+*/
+func (self *Profiler) WriteReport(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "rule\tcalls\tok\tself\ttotal\tconsumed\tbacktracked\tmemo hits\t")
+	for _, rule := range self.Rules() {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%s\t%s\t%d\t%d\t%d\t\n",
+			rule.Name, rule.Calls, rule.Successes,
+			rule.Self.Round(time.Microsecond), rule.Total.Round(time.Microsecond),
+			rule.Consumed, rule.Backtracked, rule.MemoHits)
+	}
+	return table.Flush()
+}
+
+/*
+	Writes a gzipped pprof profile, with named rules as functions and
+	calls, self time and backtracked bytes as sample values:
+	go tool pprof -top -sample_index=time profile.pb.gz
+*/
+func (self *Profiler) WritePprof(w io.Writer) error {
+	profile := &pprofBuilder{strings: map[string]int{"": 0}, table: []string{""}, functions: map[string]uint64{}}
+	var out bytes.Buffer
+
+	for _, sampleType := range [][2]string{{"calls", "count"}, {"time", "nanoseconds"}, {"backtracked", "bytes"}} {
+		var valueType bytes.Buffer
+		protoVarint(&valueType, 1, uint64(profile.intern(sampleType[0])))
+		protoVarint(&valueType, 2, uint64(profile.intern(sampleType[1])))
+		protoBytes(&out, 1, valueType.Bytes())
+	}
+
+	keys := []string{}
+	for key := range self.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sample := self.samples[key]
+		var locations, values, encoded bytes.Buffer
+		for _, name := range sample.stack {
+			appendVarint(&locations, profile.function(name))
+		}
+		for _, value := range []int64{sample.calls, sample.self, sample.backtracked} {
+			appendVarint(&values, uint64(value))
+		}
+		protoBytes(&encoded, 1, locations.Bytes())
+		protoBytes(&encoded, 2, values.Bytes())
+		protoBytes(&out, 2, encoded.Bytes())
+	}
+
+	for i, name := range profile.names {
+		id := uint64(i + 1)
+		var line, location, function bytes.Buffer
+		protoVarint(&line, 1, id)
+		protoVarint(&location, 1, id)
+		protoBytes(&location, 4, line.Bytes())
+		protoBytes(&out, 4, location.Bytes())
+		protoVarint(&function, 1, id)
+		protoVarint(&function, 2, uint64(profile.intern(name)))
+		protoVarint(&function, 3, uint64(profile.intern(name)))
+		protoBytes(&out, 5, function.Bytes())
+	}
+
+	for _, s := range profile.table {
+		protoBytes(&out, 6, []byte(s))
+	}
+	protoVarint(&out, 9, uint64(self.started.UnixNano()))
+	protoVarint(&out, 10, uint64(time.Since(self.started)))
+
+	zipped := gzip.NewWriter(w)
+	if _, err := zipped.Write(out.Bytes()); err != nil {
+		return err
+	}
+	return zipped.Close()
+}
+
+type pprofBuilder struct {
+	strings   map[string]int
+	table     []string
+	functions map[string]uint64
+	names     []string
+}
+
+func (self *pprofBuilder) intern(s string) int {
+	if index, ok := self.strings[s]; ok {
+		return index
+	}
+	self.strings[s] = len(self.table)
+	self.table = append(self.table, s)
+	return len(self.table) - 1
+}
+
+func (self *pprofBuilder) function(name string) uint64 {
+	if id, ok := self.functions[name]; ok {
+		return id
+	}
+	self.names = append(self.names, name)
+	self.functions[name] = uint64(len(self.names))
+	return uint64(len(self.names))
+}
+
+func appendVarint(out *bytes.Buffer, value uint64) {
+	for value >= 0x80 {
+		out.WriteByte(byte(value) | 0x80)
+		value >>= 7
+	}
+	out.WriteByte(byte(value))
+}
+
+func protoVarint(out *bytes.Buffer, field int, value uint64) {
+	appendVarint(out, uint64(field)<<3)
+	appendVarint(out, value)
+}
+
+func protoBytes(out *bytes.Buffer, field int, value []byte) {
+	appendVarint(out, uint64(field)<<3|2)
+	appendVarint(out, uint64(len(value)))
+	out.Write(value)
+}
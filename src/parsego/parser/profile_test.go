@@ -0,0 +1,230 @@
+package pg_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"parsego/parser"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func profile(t *testing.T, rule pg.Parser, input string) *pg.Profiler {
+	profiler := pg.NewProfiler(nil)
+	in := pg.InitParser()
+	in.SetInput(input)
+	in.SetTracer(profiler)
+	if _, err := pg.ParseWith(rule, in); err != nil {
+		t.Fatal(err)
+	}
+	return profiler
+}
+
+func TestProfilerRules(t *testing.T) {
+	profiler := profile(t, pair(), "ab=c")
+	expected := map[string]string{
+		"pair":   "calls 1 ok 1 consumed 4 backtracked 0 memo hits 0",
+		"key":    "calls 1 ok 1 consumed 2 backtracked 0 memo hits 0",
+		"number": "calls 1 ok 0 consumed 0 backtracked 1 memo hits 0",
+		"word":   "calls 1 ok 1 consumed 1 backtracked 0 memo hits 0",
+	}
+	rules := profiler.Rules()
+	if len(rules) != len(expected) {
+		t.Errorf("expected %d rules, got %d", len(expected), len(rules))
+	}
+	for i, rule := range rules {
+		actual := fmt.Sprintf("calls %d ok %d consumed %d backtracked %d memo hits %d",
+			rule.Calls, rule.Successes, rule.Consumed, rule.Backtracked, rule.MemoHits)
+		if actual != expected[rule.Name] {
+			t.Errorf("%s: expected %s, got %s", rule.Name, expected[rule.Name], actual)
+		}
+		if rule.Self > rule.Total {
+			t.Errorf("%s: self time %s exceeds the total %s", rule.Name, rule.Self, rule.Total)
+		}
+		if i > 0 && rules[i-1].Self < rule.Self {
+			t.Errorf("expected rules by decreasing self time, got %s before %s", rules[i-1].Name, rule.Name)
+		}
+	}
+
+	var report strings.Builder
+	profiler.WriteReport(&report)
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	if len(lines) != 5 || !strings.HasSuffix(strings.TrimSpace(lines[0]), "memo hits") {
+		t.Errorf("expected a header and 4 rules, got\n%s", report.String())
+	}
+
+	profiler.Reset()
+	if len(profiler.Rules()) != 0 {
+		t.Errorf("expected no rules after Reset, got %d", len(profiler.Rules()))
+	}
+}
+
+func TestProfilerMemoHits(t *testing.T) {
+	digit := func() pg.Parser {
+		return pg.Label("digit", pg.Number())
+	}
+	rule := pg.Any(
+		pg.Try(pg.Concat(digit(), pg.Character('+'))),
+		digit())
+	profiler := profile(t, rule, "1")
+	rules := profiler.Rules()
+	if len(rules) != 1 || rules[0].Calls != 2 || rules[0].MemoHits != 1 {
+		t.Errorf("expected digit called twice at 0, got %+v", rules)
+	}
+}
+
+/*
+	Reads the profile back, as stacks from the root, leaf last, with
+	their values: pair;key calls=1 backtracked=0
+*/
+func TestProfilerPprof(t *testing.T) {
+	profiler := profile(t, pair(), "ab=c")
+	var out bytes.Buffer
+	if err := profiler.WritePprof(&out); err != nil {
+		t.Fatal(err)
+	}
+	zipped, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zipped)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sampleTypes, samples, locations, functions []protoFields
+	var table []string
+	for _, field := range readProto(t, data) {
+		switch field.number {
+		case 1:
+			sampleTypes = append(sampleTypes, readProto(t, field.bytes))
+		case 2:
+			samples = append(samples, readProto(t, field.bytes))
+		case 4:
+			locations = append(locations, readProto(t, field.bytes))
+		case 5:
+			functions = append(functions, readProto(t, field.bytes))
+		case 6:
+			table = append(table, string(field.bytes))
+		}
+	}
+	if len(table) == 0 || table[0] != "" {
+		t.Fatalf("expected the string table to start with an empty string, got %q", table)
+	}
+
+	types := []string{}
+	for _, sampleType := range sampleTypes {
+		types = append(types, table[sampleType.varint(1)]+"/"+table[sampleType.varint(2)])
+	}
+	if actual := strings.Join(types, " "); actual != "calls/count time/nanoseconds backtracked/bytes" {
+		t.Errorf("unexpected sample types %s", actual)
+	}
+
+	names := map[uint64]string{}
+	for _, function := range functions {
+		names[function.varint(1)] = table[function.varint(2)]
+	}
+	locationNames := map[uint64]string{}
+	for _, location := range locations {
+		line := readProto(t, location.bytes(4))
+		locationNames[location.varint(1)] = names[line.varint(1)]
+	}
+
+	stacks := []string{}
+	for _, sample := range samples {
+		ids, values := readVarints(t, sample.bytes(1)), readVarints(t, sample.bytes(2))
+		stack := []string{}
+		for i := len(ids) - 1; i >= 0; i -= 1 {
+			stack = append(stack, locationNames[ids[i]])
+		}
+		if len(values) != 3 {
+			t.Fatalf("expected 3 values, got %d", len(values))
+		}
+		stacks = append(stacks, fmt.Sprintf("%s calls=%d backtracked=%d", strings.Join(stack, ";"), values[0], values[2]))
+	}
+	sort.Strings(stacks)
+	expected := []string{
+		"pair calls=1 backtracked=0",
+		"pair;key calls=1 backtracked=0",
+		"pair;number calls=1 backtracked=1",
+		"pair;word calls=1 backtracked=0",
+	}
+	if actual, wanted := strings.Join(stacks, "\n"), strings.Join(expected, "\n"); actual != wanted {
+		t.Errorf("expected samples\n%s\ngot\n%s", wanted, actual)
+	}
+}
+
+type protoField struct {
+	number int
+	value  uint64
+	bytes  []byte
+}
+
+type protoFields []protoField
+
+func (self protoFields) varint(number int) uint64 {
+	for _, field := range self {
+		if field.number == number {
+			return field.value
+		}
+	}
+	return 0
+}
+
+func (self protoFields) bytes(number int) []byte {
+	for _, field := range self {
+		if field.number == number {
+			return field.bytes
+		}
+	}
+	return nil
+}
+
+/*
+	The fields of a protobuf message, varints and length-delimited only
+*/
+func readProto(t *testing.T, data []byte) protoFields {
+	fields := protoFields{}
+	for len(data) > 0 {
+		key, n := uvarint(t, data)
+		data = data[n:]
+		field := protoField{number: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			field.value, n = uvarint(t, data)
+			data = data[n:]
+		case 2:
+			length, n := uvarint(t, data)
+			if uint64(len(data)-n) < length {
+				t.Fatalf("field %d overruns its message", field.number)
+			}
+			field.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func readVarints(t *testing.T, data []byte) []uint64 {
+	values := []uint64{}
+	for len(data) > 0 {
+		value, n := uvarint(t, data)
+		values = append(values, value)
+		data = data[n:]
+	}
+	return values
+}
+
+func uvarint(t *testing.T, data []byte) (uint64, int) {
+	value, n := binary.Uvarint(data)
+	if n <= 0 {
+		t.Fatal("truncated varint")
+	}
+	return value, n
+}
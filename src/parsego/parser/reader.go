@@ -0,0 +1,287 @@
+package pg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"parsego/parsetree"
+)
+
+/*
+	Implemented by States that drop the input parsed so far, like
+	ReaderState: the combinators that may backtrack (Try, Optional,
+	the iterations of Many and lookaheads) mark the position they may
+	come back to, and release it once they cannot anymore.
+	Marks are nested.
+*/
+type Backtracking interface {
+	Mark()
+	Release()
+}
+
+func mark(in State) {
+	if backtracking, ok := in.(Backtracking); ok {
+		backtracking.Mark()
+	}
+}
+
+func release(in State) {
+	if backtracking, ok := in.(Backtracking); ok {
+		backtracking.Release()
+	}
+}
+
+/*
+	The size of the reads of ReaderState
+*/
+const READER_CHUNK = 64 * 1024
+
+/*
+	A State reading its input from an io.Reader as it goes, keeping only
+	the input from the oldest marked position on (see Backtracking),
+	or from the current position when nothing is marked, and the byte
+	at the farthest position when a failed parser moved past it.
+	Memory is bounded by the longest stretch of input a parser may
+	backtrack over, not by the size of the input.
+
+	Parsers moving to positions they did not mark, and tracers asking
+	for the text of rules starting before the oldest mark, see only
+	the input still kept. GetInput returns the input kept.
+	Columns are counted from the start of the line of the position,
+	which is kept even when the bytes of the line before it are not.
+*/
+type ReaderState struct {
+	reader     io.Reader
+	err        error
+	eof        bool
+	buffer     []byte
+	start      int
+	position   int
+	lineCount  int
+	lineStart  int
+	startLine  int
+	probeCount int
+	farthest   int
+	farLine    int
+	farColumn  int
+	marks      []readerMark
+	tracer     Tracer
+	depth      int
+}
+
+/*
+	A marked position and the start of its line, found again without
+	looking for the newline before it
+*/
+type readerMark struct {
+	position  int
+	lineStart int
+}
+
+func NewReaderState(r io.Reader) *ReaderState {
+	return &ReaderState{reader: r, lineCount: 1, farLine: 1, farColumn: 1}
+}
+
+func (self *ReaderState) Next() (int, bool) {
+	if self.position > self.farthest {
+		self.farthest = self.position
+		self.farLine = self.lineCount
+		self.farColumn = self.column()
+	}
+	if !self.available(self.position) {
+		return 0, false
+	}
+
+	next := int(self.buffer[self.position-self.start])
+	self.position += 1
+	self.probeCount += 1
+	if next == '\n' {
+		self.lineCount += 1
+		self.lineStart = self.position
+	}
+	return next, true
+}
+
+func (self *ReaderState) column() int {
+	return self.position - self.lineStart + 1
+}
+
+/*
+	Reads until the byte at position is buffered, false at the end
+	of the input or on read errors
+*/
+func (self *ReaderState) available(position int) bool {
+	for position-self.start >= len(self.buffer) {
+		if self.eof || self.err != nil {
+			return false
+		}
+		self.fill()
+	}
+	return position >= self.start
+}
+
+func (self *ReaderState) fill() {
+	keep := self.position
+	if len(self.marks) > 0 && self.marks[0].position < keep {
+		keep = self.marks[0].position
+	}
+	// the byte at the farthest position is the one errors report
+	if self.farthest < keep {
+		keep = self.farthest
+	}
+	if keep < self.start {
+		keep = self.start
+	}
+	// a new buffer, as slices of the old one may still be in use
+	if len(self.buffer)+READER_CHUNK > cap(self.buffer) {
+		dropped := self.buffer[:keep-self.start]
+		if newline := bytes.LastIndexByte(dropped, '\n'); newline >= 0 {
+			self.startLine = self.start + newline + 1
+		}
+		kept := self.buffer[keep-self.start:]
+		buffer := make([]byte, len(kept), 2*len(kept)+READER_CHUNK)
+		copy(buffer, kept)
+		self.buffer, self.start = buffer, keep
+	}
+	end := len(self.buffer)
+	n, err := self.reader.Read(self.buffer[end:cap(self.buffer)])
+	self.buffer = self.buffer[:end+n]
+	if err == io.EOF {
+		self.eof = true
+	} else if err != nil {
+		self.err = err
+	}
+}
+
+func (self *ReaderState) Mark() {
+	self.marks = append(self.marks, readerMark{self.position, self.lineStart})
+}
+
+func (self *ReaderState) Release() {
+	self.marks = self.marks[:len(self.marks)-1]
+}
+
+/*
+	Not supported, the input comes from the reader
+*/
+func (self *ReaderState) SetInput(in string) {
+	panic("pg: ReaderState reads its input from an io.Reader")
+}
+
+func (self *ReaderState) GetInput() string {
+	return string(self.buffer)
+}
+
+func (self *ReaderState) GetPosition() int {
+	return self.position
+}
+
+/*
+	Moves to position, looking back for the start of its line when it is
+	neither in the line being parsed nor marked, reading on to it when
+	it is ahead. The line count is left to SetLineCount, as with ParseState.
+*/
+func (self *ReaderState) SetPosition(position int) {
+	if position < self.lineStart || position > self.position {
+		self.lineStart = self.lineStartOf(position)
+	}
+	self.position = position
+}
+
+func (self *ReaderState) lineStartOf(position int) int {
+	self.available(position - 1)
+	for i := len(self.marks) - 1; i >= 0; i -= 1 {
+		if self.marks[i].position == position {
+			return self.marks[i].lineStart
+		}
+	}
+	end := position - self.start
+	if end > len(self.buffer) {
+		end = len(self.buffer)
+	}
+	if end > 0 {
+		if newline := bytes.LastIndexByte(self.buffer[:end], '\n'); newline >= 0 {
+			return self.start + newline + 1
+		}
+	}
+	return self.startLine
+}
+
+func (self *ReaderState) GetLineCount() int {
+	return self.lineCount
+}
+
+func (self *ReaderState) SetLineCount(lineCount int) {
+	self.lineCount = lineCount
+}
+
+func (self *ReaderState) GetProbeCount() int {
+	return self.probeCount
+}
+
+func (self *ReaderState) GetFarthestPosition() int {
+	return self.farthest
+}
+
+/*
+	A copy of the input kept between start and end, as trees holding
+	slices of the buffer would keep all of it in memory
+*/
+func (self *ReaderState) GetSlice(start, end int) []byte {
+	if start < self.start {
+		start = self.start
+	}
+	if end > self.start+len(self.buffer) {
+		end = self.start + len(self.buffer)
+	}
+	if start > end {
+		start = end
+	}
+	return append([]byte{}, self.buffer[start-self.start:end-self.start]...)
+}
+
+func (self *ReaderState) GetTracer() Tracer {
+	return self.tracer
+}
+
+func (self *ReaderState) SetTracer(tracer Tracer) {
+	self.tracer = tracer
+}
+
+func (self *ReaderState) GetDepth() int {
+	return self.depth
+}
+
+func (self *ReaderState) SetDepth(depth int) {
+	self.depth = depth
+}
+
+/*
+	Parses the whole input read from r like Parse, with a ReaderState.
+	Read errors are returned as they are.
+*/
+func ParseReader(match Parser, r io.Reader) ([]*pt.ParseTree, error) {
+	in := NewReaderState(r)
+	out, ok := match(in)
+	return out, in.result(ok)
+}
+
+func (self *ReaderState) result(ok bool) error {
+	atEnd := !self.available(self.position)
+	if self.err != nil {
+		return self.err
+	}
+	if ok && atEnd {
+		return nil
+	}
+	err := &ParseError{Position: self.farthest, Line: self.farLine, Column: self.farColumn}
+	if ok && self.position > self.farthest {
+		err.Position, err.Line, err.Column = self.position, self.lineCount, self.column()
+	}
+	if self.available(err.Position) {
+		err.Message = fmt.Sprintf("unexpected %q", self.buffer[err.Position-self.start])
+	} else {
+		err.Message = "unexpected end of input"
+	}
+	return err
+}
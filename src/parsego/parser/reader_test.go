@@ -0,0 +1,97 @@
+package pg_test
+
+import (
+	"io"
+	"parsego/parser"
+	"parsego/parsetree"
+	"strings"
+	"testing"
+)
+
+/*
+	Moves to position on line, as parsers of their own may
+*/
+func jump(position, line int) pg.Parser {
+	return func(in pg.State) ([]*pt.ParseTree, bool) {
+		in.SetPosition(position)
+		in.SetLineCount(line)
+		return nil, true
+	}
+}
+
+func TestReaderJumps(t *testing.T) {
+	long := "a\n" + strings.Repeat("b", 3*pg.READER_CHUNK) + "\ncd"
+	tests := []struct {
+		name     string
+		rule     pg.Parser
+		input    string
+		expected string
+	}{
+		{"over a newline", pg.Concat(jump(3, 2), pg.Character('x')), "ab\ncd", "2:1: unexpected 'c'"},
+		{"back over a newline", pg.Concat(pg.Skip(pg.String("ab\nc")), jump(1, 1), jump(4, 2), pg.Character('x')), "ab\ncd", "2:2: unexpected 'd'"},
+		{"past the buffer", pg.Concat(jump(len(long)-2, 3), pg.Character('x')), long, "3:1: unexpected 'c'"},
+	}
+	for _, test := range tests {
+		_, err := pg.Parse(test.rule, test.input)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected Parse to fail with %s, got %v", test.name, test.expected, err)
+		}
+		_, err = pg.ParseReader(test.rule, strings.NewReader(test.input))
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s: expected ParseReader to fail with %s, got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+/*
+	Lines of key=value, until about size bytes
+*/
+type linesReader struct {
+	size int
+	line int
+	left string
+}
+
+func (self *linesReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && (self.left != "" || self.size > 0) {
+		if self.left == "" {
+			self.line += 1
+			self.left = strings.Repeat("k", self.line%7+1) + "=" + strings.Repeat("v", self.line%13) + "\n"
+			self.size -= len(self.left)
+		}
+		copied := copy(p[n:], self.left)
+		n, self.left = n+copied, self.left[copied:]
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func TestReaderBounded(t *testing.T) {
+	lines, largest := 0, 0
+	measure := func(in pg.State) ([]*pt.ParseTree, bool) {
+		lines += 1
+		if lines%1000 == 0 {
+			largest = max(largest, len(in.GetInput()))
+		}
+		return nil, true
+	}
+	line := pg.Concat(
+		pg.Many1(pg.Character('k')),
+		pg.Character('='),
+		pg.Many(pg.Character('v')),
+		pg.Character('\n'),
+		pg.Parser(measure))
+	out, err := pg.ParseReader(pg.Many(pg.Skip(line)), &linesReader{size: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 0 || lines < 10000 {
+		t.Errorf("expected no trees out of many lines, got %d trees and %d lines", len(out), lines)
+	}
+	if largest == 0 || largest > 2*pg.READER_CHUNK {
+		t.Errorf("expected at most %d bytes kept, got %d", 2*pg.READER_CHUNK, largest)
+	}
+}
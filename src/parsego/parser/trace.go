@@ -0,0 +1,214 @@
+package pg
+
+import (
+	"fmt"
+	"io"
+	"parsego/parsetree"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	TRACE_ENTER = iota
+	TRACE_SUCCESS
+	TRACE_FAILURE
+	TRACE_ALTERNATIVE
+	TRACE_CHOICE
+)
+
+/*
+	Emitted when a named rule (Specify, Recursive or Label) is entered
+	and when it exits, with success or failure. A Recursive reference to
+	a Specify or Label rule only leads to it, and is not traced.
+	Choices (Any, TryAny) emit TRACE_ALTERNATIVE before trying each
+	alternative and TRACE_CHOICE once done, with the index of the matching
+	alternative or -1. Choice identifies the choice within the process,
+	the same for equal choices built apart.
+*/
+type TraceEvent struct {
+	Kind         int
+	Rule         string
+	NodeType     int
+	Depth        int
+	Start        int
+	Position     int
+	Line         int
+	Text         []byte
+	Choice       int
+	Alternative  int
+	Alternatives int
+}
+
+/*
+	The rule name, or the node type name for Specify rules
+*/
+func (self *TraceEvent) Name(types pt.NodeTypes) string {
+	if self.Rule != "" {
+		return self.Rule
+	}
+	return types.Name(self.NodeType)
+}
+
+/*
+	Receives trace events, see Tracing
+*/
+type Tracer interface {
+	Trace(event *TraceEvent)
+}
+
+/*
+	Implemented by States that can be traced, like ParseState and
+	ReaderState: named rules and choices send events to the tracer set,
+	keeping the depth of the rules entered. Other States are not traced.
+*/
+type Tracing interface {
+	GetTracer() Tracer
+	SetTracer(tracer Tracer)
+	GetDepth() int
+	SetDepth(depth int)
+}
+
+func tracerOf(in State) Tracer {
+	if tracing, ok := in.(Tracing); ok {
+		return tracing.GetTracer()
+	}
+	return nil
+}
+
+/*
+	Adapts a function to Tracer
+*/
+type TracerFunc func(event *TraceEvent)
+
+func (self TracerFunc) Trace(event *TraceEvent) {
+	self(event)
+}
+
+/*
+	Sends events to all tracers, in order
+*/
+type MultiTracer []Tracer
+
+func (self MultiTracer) Trace(event *TraceEvent) {
+	for _, tracer := range self {
+		tracer.Trace(event)
+	}
+}
+
+func traced(expr *Expr, rule string, nodeType int, match Parser) Parser {
+	return func(in State) ([]*pt.ParseTree, bool) {
+		if describing(in, expr) {
+			return nil, false
+		}
+		tracing, ok := in.(Tracing)
+		if !ok || tracing.GetTracer() == nil {
+			return match(in)
+		}
+		tracer := tracing.GetTracer()
+		depth := tracing.GetDepth()
+		start := in.GetPosition()
+		tracer.Trace(&TraceEvent{
+			Kind:     TRACE_ENTER,
+			Rule:     rule,
+			NodeType: nodeType,
+			Depth:    depth,
+			Start:    start,
+			Position: start,
+			Line:     in.GetLineCount(),
+		})
+
+		tracing.SetDepth(depth + 1)
+		out, ok := match(in)
+		tracing.SetDepth(depth)
+
+		event := &TraceEvent{
+			Kind:     TRACE_SUCCESS,
+			Rule:     rule,
+			NodeType: nodeType,
+			Depth:    depth,
+			Start:    start,
+			Position: in.GetPosition(),
+			Line:     in.GetLineCount(),
+			Text:     slice(in, start, in.GetPosition()),
+		}
+		if !ok {
+			event.Kind = TRACE_FAILURE
+		}
+		tracer.Trace(event)
+		return out, ok
+	}
+}
+
+var choices = struct {
+	sync.Mutex
+	classes *exprClasses
+}{classes: newExprClasses()}
+
+/*
+	Numbers choices by their expression, so that the same choice built
+	several times, as by every call of a rule function, is one
+*/
+func choiceId(expr *Expr) int {
+	choices.Lock()
+	defer choices.Unlock()
+	return choices.classes.id(expr)
+}
+
+func tracedAny(choice int, matches []Parser, in State) ([]*pt.ParseTree, bool) {
+	tracing := in.(Tracing)
+	tracer := tracing.GetTracer()
+	start := in.GetPosition()
+	event := func(kind, alternative int) *TraceEvent {
+		return &TraceEvent{
+			Kind:         kind,
+			Depth:        tracing.GetDepth(),
+			Start:        start,
+			Position:     in.GetPosition(),
+			Line:         in.GetLineCount(),
+			Choice:       choice,
+			Alternative:  alternative,
+			Alternatives: len(matches),
+		}
+	}
+	for i, match := range matches {
+		tracer.Trace(event(TRACE_ALTERNATIVE, i))
+		out, ok := match(in)
+		if ok {
+			tracer.Trace(event(TRACE_CHOICE, i))
+			return out, true
+		}
+	}
+	tracer.Trace(event(TRACE_CHOICE, -1))
+	return nil, false
+}
+
+/*
+	Returns a tracer printing an indented trace to w:
+	|  IFTHENELSE? @12 line 3
+	|  |  EXPRESSION? @15 line 3
+	|  |  EXPRESSION ok "x == 1 "
+	|  IFTHENELSE failed @40 line 5 after "if x == 1 {\n}\n"
+*/
+func NewPrintTracer(w io.Writer, types pt.NodeTypes) Tracer {
+	return TracerFunc(func(event *TraceEvent) {
+		indent := strings.Repeat("|  ", event.Depth)
+		name := event.Name(types)
+		switch event.Kind {
+		case TRACE_ENTER:
+			fmt.Fprintf(w, "%s%s? @%d line %d\n", indent, name, event.Start, event.Line)
+		case TRACE_SUCCESS:
+			fmt.Fprintf(w, "%s%s ok %s\n", indent, name, quoteText(event.Text))
+		case TRACE_FAILURE:
+			fmt.Fprintf(w, "%s%s failed @%d line %d after %s\n", indent, name, event.Position, event.Line, quoteText(event.Text))
+		}
+	})
+}
+
+func quoteText(text []byte) string {
+	const max = 40
+	if len(text) > max {
+		return strconv.Quote(string(text[:max/2])) + "..." + strconv.Quote(string(text[len(text)-max/2:]))
+	}
+	return strconv.Quote(string(text))
+}
@@ -0,0 +1,222 @@
+package pg_test
+
+import (
+	"fmt"
+	"parsego/parser"
+	"parsego/parsetree"
+	"strings"
+	"testing"
+)
+
+/*
+	Records events as lines:
+	enter NAME depth @start, ok|failed NAME depth @start-position "text",
+	alternative|choice depth @position index/alternatives
+*/
+type eventRecorder struct {
+	events []string
+}
+
+func (self *eventRecorder) Trace(event *pg.TraceEvent) {
+	var line string
+	switch event.Kind {
+	case pg.TRACE_ENTER:
+		line = fmt.Sprintf("enter %s %d @%d", event.Name(nil), event.Depth, event.Start)
+	case pg.TRACE_SUCCESS, pg.TRACE_FAILURE:
+		kind := "ok"
+		if event.Kind == pg.TRACE_FAILURE {
+			kind = "failed"
+		}
+		line = fmt.Sprintf("%s %s %d @%d-%d %q", kind, event.Name(nil), event.Depth, event.Start, event.Position, event.Text)
+	case pg.TRACE_ALTERNATIVE, pg.TRACE_CHOICE:
+		kind := "alternative"
+		if event.Kind == pg.TRACE_CHOICE {
+			kind = "choice"
+		}
+		line = fmt.Sprintf("%s %d @%d %d/%d", kind, event.Depth, event.Position, event.Alternative, event.Alternatives)
+	}
+	self.events = append(self.events, line)
+}
+
+/*
+	key=value, where the value is a number or a word
+*/
+func pair() pg.Parser {
+	return pg.Label("pair",
+		pg.Concat(
+			pg.Label("key", pg.Many1(pg.Char())),
+			pg.Character('='),
+			pg.Any(
+				pg.Try(pg.Label("number", pg.Many1(pg.Number()))),
+				pg.Label("word", pg.Many1(pg.Char())))))
+}
+
+func TestTraceEvents(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"a=b", []string{
+			`enter pair 0 @0`,
+			`enter key 1 @0`,
+			`ok key 1 @0-1 "a"`,
+			`alternative 1 @2 0/2`,
+			`enter number 1 @2`,
+			`failed number 1 @2-3 "b"`,
+			`alternative 1 @2 1/2`,
+			`enter word 1 @2`,
+			`ok word 1 @2-3 "b"`,
+			`choice 1 @3 1/2`,
+			`ok pair 0 @0-3 "a=b"`,
+		}},
+		{"a=1", []string{
+			`enter pair 0 @0`,
+			`enter key 1 @0`,
+			`ok key 1 @0-1 "a"`,
+			`alternative 1 @2 0/2`,
+			`enter number 1 @2`,
+			`ok number 1 @2-3 "1"`,
+			`choice 1 @3 0/2`,
+			`ok pair 0 @0-3 "a=1"`,
+		}},
+		{"a=", []string{
+			`enter pair 0 @0`,
+			`enter key 1 @0`,
+			`ok key 1 @0-1 "a"`,
+			`alternative 1 @2 0/2`,
+			`enter number 1 @2`,
+			`failed number 1 @2-2 ""`,
+			`alternative 1 @2 1/2`,
+			`enter word 1 @2`,
+			`failed word 1 @2-2 ""`,
+			`choice 1 @2 -1/2`,
+			`failed pair 0 @0-2 "a="`,
+		}},
+	}
+	rule := pair()
+	for _, test := range tests {
+		first, second := new(eventRecorder), new(eventRecorder)
+		in := pg.InitParser()
+		in.SetInput(test.input)
+		in.SetTracer(pg.MultiTracer{first, second})
+		pg.ParseWith(rule, in)
+		expected := strings.Join(test.expected, "\n")
+		if actual := strings.Join(first.events, "\n"); actual != expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.input, expected, actual)
+		}
+		if actual := strings.Join(second.events, "\n"); actual != expected {
+			t.Errorf("%s: expected the second tracer to get\n%s\ngot\n%s", test.input, expected, actual)
+		}
+		if in.GetDepth() != 0 {
+			t.Errorf("%s: expected the depth back to 0, got %d", test.input, in.GetDepth())
+		}
+	}
+}
+
+func TestTraceNodeTypes(t *testing.T) {
+	recorder := new(eventRecorder)
+	in := pg.InitParser()
+	in.SetInput("7")
+	in.SetTracer(recorder)
+	pg.ParseWith(pg.Specify(9034, pg.Number()), in)
+	event := &pg.TraceEvent{NodeType: 9034}
+	if name := event.Name(pt.NodeTypes{9034: "DIGIT"}); name != "DIGIT" {
+		t.Errorf("expected DIGIT, got %s", name)
+	}
+	expected := `enter 9034 0 @0,ok 9034 0 @0-1 "7"`
+	if actual := strings.Join(recorder.events, ","); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
+
+/*
+	Loaded rules are traced once, by their node type or their name
+*/
+func TestTraceLoadedRules(t *testing.T) {
+	grammar := pg.MustLoadPEG(`
+		List  <- Item ("," Item)*
+		Item  <- digit+
+		digit <- [0-9]
+	`)
+	recorder := new(eventRecorder)
+	in := pg.InitParser()
+	in.SetInput("1,2")
+	in.SetTracer(recorder)
+	if _, err := pg.ParseWith(grammar.Start(), in); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		`enter 1 0 @0`,
+		`enter 2 1 @0`,
+		`enter digit 2 @0`,
+		`ok digit 2 @0-1 "1"`,
+		`enter digit 2 @1`,
+		`failed digit 2 @1-2 ","`,
+		`ok 2 1 @0-1 "1"`,
+		`enter 2 1 @2`,
+		`enter digit 2 @2`,
+		`ok digit 2 @2-3 "2"`,
+		`enter digit 2 @3`,
+		`failed digit 2 @3-3 ""`,
+		`ok 2 1 @2-3 "2"`,
+		`ok 1 0 @0-3 "1,2"`,
+	}, "\n")
+	if actual := strings.Join(recorder.events, "\n"); actual != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, actual)
+	}
+}
+
+func TestPrintTracer(t *testing.T) {
+	var out strings.Builder
+	in := pg.InitParser()
+	in.SetInput("a=")
+	in.SetTracer(pg.NewPrintTracer(&out, nil))
+	pg.ParseWith(pair(), in)
+	expected := `pair? @0 line 1
+|  key? @0 line 1
+|  key ok "a"
+|  number? @2 line 1
+|  number failed @2 line 1 after ""
+|  word? @2 line 1
+|  word failed @2 line 1 after ""
+pair failed @2 line 1 after "a="
+`
+	if out.String() != expected {
+		t.Errorf("expected\n%sgot\n%s", expected, out.String())
+	}
+}
+
+/*
+	A State implementing neither Tracing nor Slicing
+*/
+type plainState struct {
+	input    string
+	position int
+	lines    int
+}
+
+func (self *plainState) Next() (int, bool) {
+	if self.position >= len(self.input) {
+		return 0, false
+	}
+	self.position += 1
+	return int(self.input[self.position-1]), true
+}
+
+func (self *plainState) SetInput(in string)       { self.input = in }
+func (self *plainState) GetInput() string         { return self.input }
+func (self *plainState) GetPosition() int         { return self.position }
+func (self *plainState) SetPosition(position int) { self.position = position }
+func (self *plainState) SetLineCount(lines int)   { self.lines = lines }
+func (self *plainState) GetLineCount() int        { return self.lines }
+func (self *plainState) GetProbeCount() int       { return self.position }
+
+func TestPlainState(t *testing.T) {
+	out, ok := pair()(&plainState{input: "ab=12"})
+	if !ok {
+		t.Fatal("expected ab=12 to parse")
+	}
+	if len(out) != 1 || string(out[0].Value) != "ab=12" {
+		t.Errorf("expected a leaf ab=12, got %v", out)
+	}
+}
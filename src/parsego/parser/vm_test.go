@@ -0,0 +1,83 @@
+package pg_test
+
+import (
+	"errors"
+	"parsego/parser"
+	"strings"
+	"testing"
+)
+
+func TestBytecodeParserStates(t *testing.T) {
+	rule := pg.Concat(pair(), pg.Many(pg.Concat(pg.Character(';'), pair())))
+	bytecode := pg.CompileBytecode(pg.Describe(rule))
+	match := bytecode.Parser()
+	input := "a=1;bc=d"
+
+	out, err := pg.ParseReader(match, strings.NewReader(input))
+	if err != nil || sexprs(out) != `"a=1;bc=d"` {
+		t.Errorf("expected the reader to parse %s, got %s, %v", input, sexprs(out), err)
+	}
+	out, ok := match(&plainState{input: input})
+	if !ok || sexprs(out) != `"a=1;bc=d"` {
+		t.Errorf("expected a plain state to parse %s, got %s", input, sexprs(out))
+	}
+	if kind := pg.Describe(match).Kind; kind != pg.EXPR_OPAQUE {
+		t.Errorf("expected the parser to be opaque, got kind %d", kind)
+	}
+}
+
+/*
+	Running out of stack aborts the whole parse, even when
+	an alternative would match instead
+*/
+func TestBytecodeParserDepth(t *testing.T) {
+	bytecode := pg.CompileBytecode(pg.Describe(nested()))
+	bytecode.MaxDepth = 4
+	rule := pg.Any(pg.Try(bytecode.Parser()), pg.Many(pg.AnyChar()))
+	expected := "1:5: stack depth limit exceeded at 4 frames"
+	for _, input := range []string{"(1)", "((((((1))))))"} {
+		in := pg.InitParser()
+		in.SetInput(input)
+		_, err := pg.ParseWith(rule, in)
+		if len(input) == 3 {
+			if err != nil {
+				t.Errorf("%s: %s", input, err)
+			}
+		} else if !errors.Is(err, pg.ErrStackDepth) || err.Error() != expected {
+			t.Errorf("%s: expected %s, got %v", input, expected, err)
+		}
+	}
+	_, err := bytecode.Parse("((((((1))))))")
+	if !errors.Is(err, pg.ErrStackDepth) || err.Error() != expected {
+		t.Errorf("expected %s, got %v", expected, err)
+	}
+}
+
+/*
+	Leaves kept as spans merge over skipped input, into nodes
+	and with built trees the same as the closures
+*/
+func TestBytecodeLeaves(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     pg.Parser
+		input    string
+		expected string
+	}{
+		{"adjacent", pg.Concat(pg.Character('a'), pg.String("bc")), "abc", `"abc"`},
+		{"skipped", pg.Concat(pg.Character('a'), pg.SkipChar(' '), pg.Character('b'), pg.Character('c')), "a bc", `"abc"`},
+		{"node", pg.Concat(pg.Character('x'), pg.Specify(9046, pg.Many1(pg.Number()))), "x12", `"x" (9046 "12")`},
+		{"after node", pg.Concat(pg.Specify(9047, pg.Character('x')), pg.Character('a'), pg.Character('b')), "xab", `(9047 "x") "a" "b"`},
+		{"backtrack", pg.Any(pg.Try(pg.Concat(pg.String("ab"), pg.Character('x'))), pg.Concat(pg.String("ab"), pg.Character('c'))), "abc", `"abc"`},
+	}
+	for _, test := range tests {
+		closures, err := pg.Parse(test.rule, test.input)
+		if err != nil || sexprs(closures) != test.expected {
+			t.Errorf("%s: expected closures to give %s, got %s, %v", test.name, test.expected, sexprs(closures), err)
+		}
+		out, err := pg.CompileBytecode(pg.Describe(test.rule)).Parse(test.input)
+		if err != nil || sexprs(out) != test.expected {
+			t.Errorf("%s: expected %s, got %s, %v", test.name, test.expected, sexprs(out), err)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package pt
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+	Selects what Equal and Diff compare besides types and structure
+*/
+type EqualOptions struct {
+	IgnorePositions bool
+	IgnoreValues    bool
+	IgnoreIds       bool
+}
+
+/*
+	Reports whether two trees have the same structure, types and,
+	unless ignored, values, positions and actual ids
+*/
+func Equal(a, b *ParseTree, opts EqualOptions) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !sameNode(a, b, opts) || len(a.Children) != len(b.Children) {
+		return false
+	}
+	for i := range a.Children {
+		if !Equal(a.Children[i], b.Children[i], opts) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameNode(a, b *ParseTree, opts EqualOptions) bool {
+	return a.Type == b.Type &&
+		(opts.IgnoreValues || bytes.Equal(a.Value, b.Value)) &&
+		(opts.IgnorePositions || a.Position == b.Position) &&
+		(opts.IgnoreIds || a.ActualId == b.ActualId)
+}
+
+type EditOp int
+
+const (
+	EditInsert EditOp = iota
+	EditDelete
+	EditMove
+	EditChange
+)
+
+/*
+	A single difference between two trees.
+	Path locates Old in the first tree, NewPath locates New in the second.
+*/
+type Edit struct {
+	Op      EditOp
+	Path    Path
+	NewPath Path
+	Old     *ParseTree
+	New     *ParseTree
+	opts    EqualOptions
+}
+
+/*
+	A node location: the type and child index of each node from the root
+*/
+type Path []PathStep
+
+/*
+	Nil marks a nil child, which has no type
+*/
+type PathStep struct {
+	Type  int
+	Index int
+	Nil   bool
+}
+
+func (self Path) Format(types NodeTypes) string {
+	var out strings.Builder
+	for i, step := range self {
+		out.WriteString("/")
+		if step.Nil {
+			out.WriteString("nil")
+		} else {
+			out.WriteString(types.Name(step.Type))
+		}
+		if i > 0 {
+			fmt.Fprintf(&out, "[%d]", step.Index)
+		}
+	}
+	return out.String()
+}
+
+/*
+	The edits turning a tree into another, empty if they are equal
+*/
+type EditScript []Edit
+
+/*
+	Computes an edit script from a to b: children are aligned on their
+	longest common subsequence of equal subtrees, remaining nodes of the
+	same type are compared recursively, and subtrees deleted in one place
+	and inserted in another are reported as moves
+*/
+func Diff(a, b *ParseTree, opts EqualOptions) EditScript {
+	differ := &differ{opts: opts}
+	switch {
+	case a == nil && b == nil:
+	case a == nil:
+		differ.inserted = append(differ.inserted, Edit{Op: EditInsert, NewPath: Path{{Type: b.Type}}, New: b})
+	case b == nil:
+		differ.deleted = append(differ.deleted, Edit{Op: EditDelete, Path: Path{{Type: a.Type}}, Old: a})
+	case a.Type != b.Type:
+		differ.edits = append(differ.edits, Edit{Op: EditChange, Path: Path{{Type: a.Type}}, NewPath: Path{{Type: b.Type}}, Old: a, New: b, opts: opts})
+	default:
+		differ.diff(a, b, Path{{Type: a.Type}}, Path{{Type: b.Type}})
+	}
+	return differ.script()
+}
+
+type differ struct {
+	opts     EqualOptions
+	edits    EditScript
+	deleted  EditScript
+	inserted EditScript
+}
+
+func (self *differ) diff(a, b *ParseTree, pathA, pathB Path) {
+	if !sameNode(a, b, self.opts) {
+		self.edits = append(self.edits, Edit{Op: EditChange, Path: pathA, NewPath: pathB, Old: a, New: b, opts: self.opts})
+	}
+
+	childrenA, childrenB := a.Children, b.Children
+	common := self.lcs(childrenA, childrenB)
+	i, j := 0, 0
+	for _, anchor := range append(common, [2]int{len(childrenA), len(childrenB)}) {
+		self.diffGap(childrenA[i:anchor[0]], childrenB[j:anchor[1]], i, j, pathA, pathB)
+		i, j = anchor[0]+1, anchor[1]+1
+	}
+}
+
+func (self *differ) diffGap(childrenA, childrenB []*ParseTree, offsetA, offsetB int, pathA, pathB Path) {
+	used := make([]bool, len(childrenB))
+	for i, childA := range childrenA {
+		childPathA := extend(pathA, childA, offsetA+i)
+		paired := false
+		for j, childB := range childrenB {
+			if used[j] || childA == nil || childB == nil || childA.Type != childB.Type {
+				continue
+			}
+			used[j], paired = true, true
+			self.diff(childA, childB, childPathA, extend(pathB, childB, offsetB+j))
+			break
+		}
+		if !paired {
+			self.deleted = append(self.deleted, Edit{Op: EditDelete, Path: childPathA, Old: childA})
+		}
+	}
+	for j, childB := range childrenB {
+		if !used[j] {
+			self.inserted = append(self.inserted, Edit{Op: EditInsert, NewPath: extend(pathB, childB, offsetB+j), New: childB})
+		}
+	}
+}
+
+func extend(path Path, node *ParseTree, index int) Path {
+	step := PathStep{Index: index, Nil: node == nil}
+	if node != nil {
+		step.Type = node.Type
+	}
+	extended := make(Path, len(path), len(path)+1)
+	copy(extended, path)
+	return append(extended, step)
+}
+
+/*
+	Index pairs of the longest common subsequence of equal children
+*/
+func (self *differ) lcs(a, b []*ParseTree) [][2]int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i -= 1 {
+		for j := len(b) - 1; j >= 0; j -= 1 {
+			if Equal(a[i], b[j], self.opts) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	pairs := [][2]int{}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case Equal(a[i], b[j], self.opts):
+			pairs = append(pairs, [2]int{i, j})
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i += 1
+		default:
+			j += 1
+		}
+	}
+	return pairs
+}
+
+func (self *differ) script() EditScript {
+	used := make([]bool, len(self.inserted))
+	for _, deleted := range self.deleted {
+		moved := false
+		for j, inserted := range self.inserted {
+			if !used[j] && Equal(deleted.Old, inserted.New, self.opts) {
+				used[j], moved = true, true
+				self.edits = append(self.edits, Edit{Op: EditMove, Path: deleted.Path, NewPath: inserted.NewPath, Old: deleted.Old, New: inserted.New})
+				break
+			}
+		}
+		if !moved {
+			self.edits = append(self.edits, deleted)
+		}
+	}
+	for j, inserted := range self.inserted {
+		if !used[j] {
+			self.edits = append(self.edits, inserted)
+		}
+	}
+	return self.edits
+}
+
+/*
+	Renders one edit per line:
+	~ /PROGRAM/ASSIGNMENT[0]/IDENTIFIER[0]: value "a" -> "b"
+	- /PROGRAM/BREAK[2]: (BREAK)
+	+ /PROGRAM/CONTINUE[2]: (CONTINUE)
+	> /PROGRAM/FOR[1] -> /PROGRAM/FOR[3]: (FOR ...)
+*/
+func (self EditScript) Format(types NodeTypes) string {
+	var out strings.Builder
+	for _, edit := range self {
+		switch edit.Op {
+		case EditInsert:
+			fmt.Fprintf(&out, "+ %s: %s\n", edit.NewPath.Format(types), summary(edit.New, types))
+		case EditDelete:
+			fmt.Fprintf(&out, "- %s: %s\n", edit.Path.Format(types), summary(edit.Old, types))
+		case EditMove:
+			fmt.Fprintf(&out, "> %s -> %s: %s\n", edit.Path.Format(types), edit.NewPath.Format(types), summary(edit.Old, types))
+		case EditChange:
+			fmt.Fprintf(&out, "~ %s: %s\n", edit.Path.Format(types), changes(edit.Old, edit.New, edit.opts, types))
+		}
+	}
+	return out.String()
+}
+
+func (self EditScript) String() string {
+	return self.Format(nil)
+}
+
+func changes(a, b *ParseTree, opts EqualOptions, types NodeTypes) string {
+	described := []string{}
+	if a.Type != b.Type {
+		described = append(described, fmt.Sprintf("%s -> %s", summary(a, types), summary(b, types)))
+	} else {
+		if !opts.IgnoreValues && !bytes.Equal(a.Value, b.Value) {
+			described = append(described, fmt.Sprintf("value %q -> %q", a.Value, b.Value))
+		}
+		if !opts.IgnorePositions && a.Position != b.Position {
+			described = append(described, fmt.Sprintf("position %s -> %s", span(a.Position), span(b.Position)))
+		}
+		if !opts.IgnoreIds && a.ActualId != b.ActualId {
+			described = append(described, fmt.Sprintf("id %q -> %q", a.ActualId, b.ActualId))
+		}
+	}
+	return strings.Join(described, ", ")
+}
+
+func span(position InputPosition) string {
+	return fmt.Sprintf("%d:%d-%d:%d", position.StartLine, position.StartPosition, position.EndLine, position.EndPosition)
+}
+
+func summary(node *ParseTree, types NodeTypes) string {
+	if node == nil {
+		return "()"
+	}
+	out := "(" + types.Name(node.Type)
+	if len(node.Value) > 0 {
+		out += " " + strconv.Quote(string(node.Value))
+	}
+	if len(node.Children) > 0 {
+		out += " ..."
+	}
+	return out + ")"
+}
@@ -0,0 +1,125 @@
+package pt
+
+import (
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	renamed := sampleProgram()
+	renamed.Children[0].ActualId = "loop"
+	moved := sampleProgram()
+	moved.Children[2].Position = InputPosition{StartPosition: 1, EndPosition: 2}
+	changed := sampleProgram()
+	changed.Children[2].Value = []byte("other")
+	shorter := sampleProgram()
+	shorter.Children = shorter.Children[:2]
+
+	tests := []struct {
+		name     string
+		a, b     *ParseTree
+		opts     EqualOptions
+		expected bool
+	}{
+		{"same", sampleProgram(), sampleProgram(), EqualOptions{}, true},
+		{"nil", nil, nil, EqualOptions{}, true},
+		{"nil and tree", nil, sampleProgram(), EqualOptions{}, false},
+		{"ids", sampleProgram(), renamed, EqualOptions{}, false},
+		{"ignored ids", sampleProgram(), renamed, EqualOptions{IgnoreIds: true}, true},
+		{"positions", sampleProgram(), moved, EqualOptions{}, false},
+		{"ignored positions", sampleProgram(), moved, EqualOptions{IgnorePositions: true}, true},
+		{"values", sampleProgram(), changed, EqualOptions{}, false},
+		{"ignored values", sampleProgram(), changed, EqualOptions{IgnoreValues: true}, true},
+		{"children", sampleProgram(), shorter, EqualOptions{IgnoreIds: true, IgnorePositions: true, IgnoreValues: true}, false},
+	}
+	for _, test := range tests {
+		if actual := Equal(test.a, test.b, test.opts); actual != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		edit     func(tree *ParseTree) *ParseTree
+		expected string
+	}{
+		{"equal", func(tree *ParseTree) *ParseTree {
+			return tree
+		}, ""},
+		{"value", func(tree *ParseTree) *ParseTree {
+			tree.Children[0].Children[0].Value = []byte("j")
+			return tree
+		}, "~ /PROGRAM/FOR[0]/IDENTIFIER[0]: value \"i\" -> \"j\"\n"},
+		{"position and id", func(tree *ParseTree) *ParseTree {
+			tree.Children[1].Position = InputPosition{StartPosition: 4, EndPosition: 6, StartLine: 1, EndLine: 2}
+			tree.Children[1].ActualId = "block"
+			return tree
+		}, "~ /PROGRAM/BLOCK[1]: position 0:0-0:0 -> 1:4-2:6, id \"empty\" -> \"block\"\n"},
+		{"move", func(tree *ParseTree) *ParseTree {
+			tree.Children[1], tree.Children[2] = tree.Children[2], tree.Children[1]
+			return tree
+		}, "> /PROGRAM/BLOCK[1] -> /PROGRAM/BLOCK[2]: (BLOCK)\n"},
+		{"nested move", func(tree *ParseTree) *ParseTree {
+			body := tree.Children[0].Children[1]
+			call := body.Children[0]
+			body.Children = body.Children[1:]
+			tree.Children = append(tree.Children, call)
+			return tree
+		}, "> /PROGRAM/FOR[0]/BLOCK[1]/CALL[0] -> /PROGRAM/CALL[3]: (CALL ...)\n"},
+		{"insert and delete", func(tree *ParseTree) *ParseTree {
+			tree.Children[2] = node("break", 6, "")
+			return tree
+		}, "- /PROGRAM/IDENTIFIER[2]: (IDENTIFIER \"item\")\n+ /PROGRAM/BREAK[2]: (BREAK)\n"},
+		{"root type", func(tree *ParseTree) *ParseTree {
+			return tree.Children[0]
+		}, "~ /PROGRAM: (PROGRAM ...) -> (FOR ...)\n"},
+		{"deleted root", func(tree *ParseTree) *ParseTree {
+			return nil
+		}, "- /PROGRAM: (PROGRAM ...)\n"},
+		{"nil child", func(tree *ParseTree) *ParseTree {
+			tree.Children[1] = nil
+			return tree
+		}, "- /PROGRAM/BLOCK[1]: (BLOCK)\n+ /PROGRAM/nil[1]: ()\n"},
+		{"negative type", func(tree *ParseTree) *ParseTree {
+			tree.Children[1] = &ParseTree{Type: -1, ActualId: "empty"}
+			return tree
+		}, "- /PROGRAM/BLOCK[1]: (BLOCK)\n+ /PROGRAM/-1[1]: (-1)\n"},
+	}
+	for _, test := range tests {
+		script := Diff(sampleProgram(), test.edit(sampleProgram()), EqualOptions{})
+		if actual := script.Format(queryTypes); actual != test.expected {
+			t.Errorf("%s: expected\n%sgot\n%s", test.name, test.expected, actual)
+		}
+	}
+	if script := Diff(nil, sampleProgram(), EqualOptions{}); script.String() != "+ /1: (1 ...)\n" {
+		t.Errorf("expected the root to be inserted, got %s", script)
+	}
+}
+
+func TestDiffNilPath(t *testing.T) {
+	a, b := sampleProgram(), sampleProgram()
+	a.Children[1] = nil
+	b.Children[1] = &ParseTree{Type: -1}
+	script := Diff(a, b, EqualOptions{})
+	if len(script) != 2 || script[0].Op != EditDelete || script[1].Op != EditInsert {
+		t.Fatalf("expected a deletion and an insertion, got %s", script)
+	}
+	deleted, inserted := script[0].Path[1], script[1].NewPath[1]
+	if !deleted.Nil || inserted.Nil || inserted.Type != -1 {
+		t.Errorf("expected a nil step and a step of type -1, got %+v and %+v", deleted, inserted)
+	}
+	expected := "- /PROGRAM/nil[1]: ()\n+ /PROGRAM/-1[1]: (-1)\n"
+	if actual := script.Format(queryTypes); actual != expected {
+		t.Errorf("expected\n%sgot\n%s", expected, actual)
+	}
+}
+
+func TestDiffIgnoring(t *testing.T) {
+	b := sampleProgram()
+	b.Children[2].Value = []byte("other")
+	b.Children[0].ActualId = "loop"
+	if script := Diff(sampleProgram(), b, EqualOptions{IgnoreValues: true, IgnoreIds: true}); len(script) != 0 {
+		t.Errorf("expected no edits, got %s", script)
+	}
+}
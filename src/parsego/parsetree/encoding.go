@@ -0,0 +1,183 @@
+package pt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"unicode/utf8"
+)
+
+/*
+	JSON encoding, stable across releases:
+	{"type": 1, "value": "...", "position": {...}, "actualId": "...", "children": [...]}
+	Values that are not valid UTF-8 are stored base64-encoded in "valueBytes"
+*/
+func (self *ParseTree) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSONNode(self, nil))
+}
+
+func (self *ParseTree) UnmarshalJSON(data []byte) error {
+	node := new(jsonNode)
+	if err := json.Unmarshal(data, node); err != nil {
+		return err
+	}
+	*self = *fromJSONNode(node)
+	return nil
+}
+
+func fromJSONNode(node *jsonNode) *ParseTree {
+	if node == nil {
+		return nil
+	}
+	out := new(ParseTree)
+	out.Type = node.Type
+	if len(node.ValueBytes) > 0 {
+		out.Value = node.ValueBytes
+	} else if len(node.Value) > 0 {
+		out.Value = []byte(node.Value)
+	}
+	out.ActualId = node.ActualId
+	out.Position = InputPosition{
+		StartPosition: node.Position.Start,
+		EndPosition:   node.Position.End,
+		StartLine:     node.Position.StartLine,
+		EndLine:       node.Position.EndLine,
+	}
+	for _, child := range node.Children {
+		out.Children = append(out.Children, fromJSONNode(child))
+	}
+	return out
+}
+
+/*
+	Compact binary encoding, also used by encoding/gob.
+	A version byte is followed by the nodes in pre-order, each one as:
+	presence byte, type, value, positions, actual id, children count
+*/
+const BINARY_VERSION = 1
+
+var ErrInvalidBinary = errors.New("pt: invalid binary parse tree")
+
+func (self *ParseTree) MarshalBinary() ([]byte, error) {
+	data := []byte{BINARY_VERSION}
+	return appendBinary(data, self), nil
+}
+
+func appendBinary(data []byte, node *ParseTree) []byte {
+	if node == nil {
+		return append(data, 0)
+	}
+	data = append(data, 1)
+	data = binary.AppendVarint(data, int64(node.Type))
+	data = binary.AppendUvarint(data, uint64(len(node.Value)))
+	data = append(data, node.Value...)
+	data = binary.AppendVarint(data, int64(node.Position.StartPosition))
+	data = binary.AppendVarint(data, int64(node.Position.EndPosition))
+	data = binary.AppendVarint(data, int64(node.Position.StartLine))
+	data = binary.AppendVarint(data, int64(node.Position.EndLine))
+	data = binary.AppendUvarint(data, uint64(len(node.ActualId)))
+	data = append(data, node.ActualId...)
+	data = binary.AppendUvarint(data, uint64(len(node.Children)))
+	for _, child := range node.Children {
+		data = appendBinary(data, child)
+	}
+	return data
+}
+
+func (self *ParseTree) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != BINARY_VERSION {
+		return ErrInvalidBinary
+	}
+	decoder := &binaryDecoder{data: data[1:]}
+	node := decoder.readNode()
+	if decoder.err != nil {
+		return decoder.err
+	}
+	if node == nil || len(decoder.data) > 0 {
+		return ErrInvalidBinary
+	}
+	*self = *node
+	return nil
+}
+
+type binaryDecoder struct {
+	data []byte
+	err  error
+}
+
+func (self *binaryDecoder) readNode() *ParseTree {
+	if self.err != nil || self.readByte() == 0 {
+		return nil
+	}
+	node := new(ParseTree)
+	node.Type = self.readVarint()
+	if value := self.readBytes(); len(value) > 0 {
+		node.Value = value
+	}
+	node.Position.StartPosition = self.readVarint()
+	node.Position.EndPosition = self.readVarint()
+	node.Position.StartLine = self.readVarint()
+	node.Position.EndLine = self.readVarint()
+	node.ActualId = string(self.readBytes())
+	count := self.readUvarint()
+	if count > uint64(len(self.data)) {
+		self.err = ErrInvalidBinary
+		return nil
+	}
+	for i := uint64(0); i < count && self.err == nil; i += 1 {
+		node.Children = append(node.Children, self.readNode())
+	}
+	return node
+}
+
+func (self *binaryDecoder) readByte() byte {
+	if len(self.data) == 0 {
+		self.err = ErrInvalidBinary
+		return 0
+	}
+	b := self.data[0]
+	self.data = self.data[1:]
+	return b
+}
+
+func (self *binaryDecoder) readVarint() int {
+	value, n := binary.Varint(self.data)
+	if n <= 0 {
+		self.err = ErrInvalidBinary
+		return 0
+	}
+	self.data = self.data[n:]
+	return int(value)
+}
+
+func (self *binaryDecoder) readUvarint() uint64 {
+	value, n := binary.Uvarint(self.data)
+	if n <= 0 {
+		self.err = ErrInvalidBinary
+		return 0
+	}
+	self.data = self.data[n:]
+	return value
+}
+
+func (self *binaryDecoder) readBytes() []byte {
+	length := self.readUvarint()
+	if self.err != nil {
+		return nil
+	}
+	if length > uint64(len(self.data)) {
+		self.err = ErrInvalidBinary
+		return nil
+	}
+	out := make([]byte, length)
+	copy(out, self.data)
+	self.data = self.data[length:]
+	return out
+}
+
+func jsonValue(value []byte) (string, []byte) {
+	if utf8.Valid(value) {
+		return string(value), nil
+	}
+	return "", value
+}
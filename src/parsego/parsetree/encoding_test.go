@@ -0,0 +1,88 @@
+package pt
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func sampleTree() *ParseTree {
+	return &ParseTree{
+		Type:     34,
+		Position: InputPosition{StartPosition: 0, EndPosition: 18, StartLine: 1, EndLine: 2},
+		ActualId: "main",
+		Children: []*ParseTree{
+			{
+				Type:     6,
+				Position: InputPosition{StartPosition: 1, EndPosition: 9, StartLine: 1, EndLine: 1},
+				Children: []*ParseTree{
+					{Type: 1, Value: []byte("test"), Position: InputPosition{1, 5, 1, 1}},
+					{Type: 2, Value: []byte("42"), Position: InputPosition{7, 9, 1, 1}},
+				},
+			},
+			{Type: 3, Value: []byte{0xff, 'a', 0x00}, Position: InputPosition{10, 13, 2, 2}},
+			{Type: -1, ActualId: "negative"},
+		},
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	tree := sampleTree()
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(ParseTree)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tree, decoded) {
+		t.Errorf("JSON round trip mismatch:\n%s", data)
+	}
+
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Errorf("JSON encoding is not stable:\n%s\n%s", data, again)
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	tree := sampleTree()
+	data, err := tree.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(ParseTree)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tree, decoded) {
+		t.Errorf("binary round trip mismatch")
+	}
+
+	for i := 0; i < len(data); i += 1 {
+		if err := new(ParseTree).UnmarshalBinary(data[:i]); err == nil {
+			t.Errorf("truncated input of %d bytes decoded without error", i)
+		}
+	}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	tree := sampleTree()
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(tree); err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(ParseTree)
+	if err := gob.NewDecoder(&buffer).Decode(decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tree, decoded) {
+		t.Errorf("gob round trip mismatch")
+	}
+}
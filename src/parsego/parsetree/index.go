@@ -0,0 +1,155 @@
+package pt
+
+import (
+	"sort"
+)
+
+/*
+	Upward, sideways and positional navigation over a tree.
+	Nodes spanning no input (such as unspecified fragments) can be
+	navigated to, but are never returned by positional lookups.
+	The index must be rebuilt if the tree changes.
+*/
+type Index struct {
+	root       *ParseTree
+	parents    map[*ParseTree]*ParseTree
+	childIndex map[*ParseTree]int
+	spans      map[*ParseTree][]*ParseTree
+}
+
+func NewIndex(root *ParseTree) *Index {
+	index := &Index{
+		root:       root,
+		parents:    make(map[*ParseTree]*ParseTree),
+		childIndex: make(map[*ParseTree]int),
+		spans:      make(map[*ParseTree][]*ParseTree),
+	}
+	root.Visit(VisitorFuncs{
+		EnterFunc: func(node *ParseTree, ancestors []*ParseTree) WalkAction {
+			spanning := []*ParseTree{}
+			for i, child := range node.Children {
+				if child == nil {
+					continue
+				}
+				index.parents[child] = node
+				index.childIndex[child] = i
+				if spans(child) {
+					spanning = append(spanning, child)
+				}
+			}
+			sort.SliceStable(spanning, func(i, j int) bool {
+				return spanning[i].Position.StartPosition < spanning[j].Position.StartPosition
+			})
+			index.spans[node] = spanning
+			return Continue
+		},
+	})
+	return index
+}
+
+func spans(node *ParseTree) bool {
+	return node.Position.EndPosition > node.Position.StartPosition
+}
+
+func covers(node *ParseTree, offset int) bool {
+	return node.Position.StartPosition <= offset && offset < node.Position.EndPosition
+}
+
+func (self *Index) Root() *ParseTree {
+	return self.root
+}
+
+/*
+	Returns the parent of node, nil for the root
+*/
+func (self *Index) Parent(node *ParseTree) *ParseTree {
+	return self.parents[node]
+}
+
+/*
+	Returns the ancestors of node, from the root down
+*/
+func (self *Index) Ancestors(node *ParseTree) []*ParseTree {
+	ancestors := []*ParseTree{}
+	for parent := self.parents[node]; parent != nil; parent = self.parents[parent] {
+		ancestors = append(ancestors, parent)
+	}
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+	return ancestors
+}
+
+func (self *Index) NextSibling(node *ParseTree) *ParseTree {
+	return self.sibling(node, 1)
+}
+
+func (self *Index) PrevSibling(node *ParseTree) *ParseTree {
+	return self.sibling(node, -1)
+}
+
+func (self *Index) sibling(node *ParseTree, step int) *ParseTree {
+	parent := self.parents[node]
+	if parent == nil {
+		return nil
+	}
+	for i := self.childIndex[node] + step; i >= 0 && i < len(parent.Children); i += step {
+		if parent.Children[i] != nil {
+			return parent.Children[i]
+		}
+	}
+	return nil
+}
+
+/*
+	Returns the innermost node whose input span contains offset,
+	nil if none does
+*/
+func (self *Index) NodeAt(offset int) *ParseTree {
+	if self.root == nil || !covers(self.root, offset) {
+		return nil
+	}
+	node := self.root
+	for {
+		children := self.spans[node]
+		i := sort.Search(len(children), func(i int) bool {
+			return children[i].Position.EndPosition > offset
+		})
+		if i == len(children) || !covers(children[i], offset) {
+			return node
+		}
+		node = children[i]
+	}
+}
+
+/*
+	Returns all nodes whose input span overlaps [start, end), in pre-order;
+	for an empty range, those containing start
+*/
+func (self *Index) NodesInRange(start, end int) []*ParseTree {
+	found := []*ParseTree{}
+	if self.root != nil && overlaps(self.root, start, end) {
+		self.collect(self.root, start, end, &found)
+	}
+	return found
+}
+
+func overlaps(node *ParseTree, start, end int) bool {
+	if start == end {
+		return covers(node, start)
+	}
+	return node.Position.StartPosition < end && start < node.Position.EndPosition
+}
+
+func (self *Index) collect(node *ParseTree, start, end int, found *[]*ParseTree) {
+	*found = append(*found, node)
+	children := self.spans[node]
+	i := sort.Search(len(children), func(i int) bool {
+		return children[i].Position.EndPosition > start
+	})
+	for ; i < len(children) && children[i].Position.StartPosition <= end; i += 1 {
+		if overlaps(children[i], start, end) {
+			self.collect(children[i], start, end, found)
+		}
+	}
+}
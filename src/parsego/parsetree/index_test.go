@@ -0,0 +1,127 @@
+package pt
+
+import (
+	"testing"
+)
+
+/*
+	The sample program over "abcdefghij", empty spanning no input
+	and a nil child between it and item:
+	program [0,10) for [0,6) i [0,1) body [2,6) call [2,5) print [2,5)
+	break [5,6) empty [6,6) item [7,10)
+*/
+func indexedProgram() (*Index, map[string]*ParseTree) {
+	tree := sampleProgram()
+	nodes := map[string]*ParseTree{}
+	spans := map[string][2]int{
+		"program": {0, 10}, "for": {0, 6}, "i": {0, 1}, "body": {2, 6}, "call": {2, 5},
+		"print": {2, 5}, "break": {5, 6}, "empty": {6, 6}, "item": {7, 10},
+	}
+	tree.Visit(VisitorFuncs{
+		EnterFunc: func(node *ParseTree, ancestors []*ParseTree) WalkAction {
+			nodes[node.ActualId] = node
+			span := spans[node.ActualId]
+			node.Position = InputPosition{StartPosition: span[0], EndPosition: span[1]}
+			return Continue
+		},
+	})
+	InsertChildren(tree, 2, nil)
+	return NewIndex(tree), nodes
+}
+
+func TestIndexNavigation(t *testing.T) {
+	index, nodes := indexedProgram()
+	if index.Root() != nodes["program"] {
+		t.Errorf("expected the root to be program, got %s", index.Root().ActualId)
+	}
+	tests := []struct {
+		name     string
+		actual   *ParseTree
+		expected string
+	}{
+		{"Parent(program)", index.Parent(nodes["program"]), ""},
+		{"Parent(print)", index.Parent(nodes["print"]), "call"},
+		{"Parent(item)", index.Parent(nodes["item"]), "program"},
+		{"NextSibling(i)", index.NextSibling(nodes["i"]), "body"},
+		{"NextSibling(body)", index.NextSibling(nodes["body"]), ""},
+		{"PrevSibling(i)", index.PrevSibling(nodes["i"]), ""},
+		{"PrevSibling(break)", index.PrevSibling(nodes["break"]), "call"},
+		{"NextSibling(empty)", index.NextSibling(nodes["empty"]), "item"},
+		{"PrevSibling(item)", index.PrevSibling(nodes["item"]), "empty"},
+		{"NextSibling(program)", index.NextSibling(nodes["program"]), ""},
+		{"NextSibling(print)", index.NextSibling(nodes["print"]), ""},
+		{"Parent(unknown)", index.Parent(node("unknown", 1, "")), ""},
+	}
+	for _, test := range tests {
+		actual := ""
+		if test.actual != nil {
+			actual = test.actual.ActualId
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, actual)
+		}
+	}
+	if actual := ids(index.Ancestors(nodes["print"])); actual != "program for body call" {
+		t.Errorf("expected the ancestors of print to be program for body call, got %s", actual)
+	}
+	if actual := ids(index.Ancestors(nodes["program"])); actual != "" {
+		t.Errorf("expected the root to have no ancestors, got %s", actual)
+	}
+}
+
+func TestIndexNodeAt(t *testing.T) {
+	index, _ := indexedProgram()
+	tests := []struct {
+		offset   int
+		expected string
+	}{
+		{-1, ""},
+		{0, "i"},
+		{1, "for"},
+		{2, "print"},
+		{4, "print"},
+		{5, "break"},
+		{6, "program"},
+		{7, "item"},
+		{9, "item"},
+		{10, ""},
+	}
+	for _, test := range tests {
+		actual := ""
+		if node := index.NodeAt(test.offset); node != nil {
+			actual = node.ActualId
+		}
+		if actual != test.expected {
+			t.Errorf("NodeAt(%d): expected %q, got %q", test.offset, test.expected, actual)
+		}
+	}
+}
+
+func TestIndexNodesInRange(t *testing.T) {
+	index, _ := indexedProgram()
+	tests := []struct {
+		start, end int
+		expected   string
+	}{
+		{0, 1, "program for i"},
+		{1, 2, "program for"},
+		{0, 10, "program for i body call print break item"},
+		{5, 7, "program for body break"},
+		{6, 7, "program"},
+		{9, 20, "program item"},
+		{10, 20, ""},
+		{-5, 0, ""},
+		{0, 0, "program for i"},
+		{2, 2, "program for body call print"},
+		{3, 3, "program for body call print"},
+		{5, 5, "program for body break"},
+		{6, 6, "program"},
+		{7, 7, "program item"},
+		{10, 10, ""},
+	}
+	for _, test := range tests {
+		if actual := ids(index.NodesInRange(test.start, test.end)); actual != test.expected {
+			t.Errorf("NodesInRange(%d, %d): expected %q, got %q", test.start, test.end, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package pt
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/*
+	Maps node types to printable names
+*/
+type NodeTypes map[int]string
+
+func (self NodeTypes) Name(nodeType int) string {
+	if name, ok := self[nodeType]; ok {
+		return name
+	}
+	return strconv.Itoa(nodeType)
+}
+
+/*
+	Writes a tree in any of the supported formats
+*/
+type TreeWriter func(w io.Writer, tree *ParseTree, types NodeTypes) error
+
+/*
+	Writes an indented text dump, one node per line:
+	|  |  IDENTIFIER [value]
+*/
+func WriteText(w io.Writer, tree *ParseTree, types NodeTypes) error {
+	out := bufio.NewWriter(w)
+	writeText(out, 0, tree, types)
+	return out.Flush()
+}
+
+func writeText(out *bufio.Writer, level int, node *ParseTree, types NodeTypes) {
+	if node == nil {
+		return
+	}
+	for i := 0; i < level; i += 1 {
+		out.WriteString("|  ")
+	}
+	fmt.Fprintf(out, "%s [%s]\n", types.Name(node.Type), node.Value)
+	for _, child := range node.Children {
+		writeText(out, level+1, child, types)
+	}
+}
+
+/*
+	Writes an S-expression, one node per line:
+	(FOR
+	  (FOR_INIT ...)
+	  (IDENTIFIER "i"))
+*/
+func WriteSExpr(w io.Writer, tree *ParseTree, types NodeTypes) error {
+	out := bufio.NewWriter(w)
+	writeSExpr(out, 0, tree, types)
+	out.WriteString("\n")
+	return out.Flush()
+}
+
+func writeSExpr(out *bufio.Writer, level int, node *ParseTree, types NodeTypes) {
+	if node == nil {
+		out.WriteString("()")
+		return
+	}
+	out.WriteString("(")
+	out.WriteString(types.Name(node.Type))
+	if len(node.Value) > 0 {
+		out.WriteString(" ")
+		out.WriteString(strconv.Quote(string(node.Value)))
+	}
+	for _, child := range node.Children {
+		out.WriteString("\n")
+		out.WriteString(strings.Repeat("  ", level+1))
+		writeSExpr(out, level+1, child, types)
+	}
+	out.WriteString(")")
+}
+
+type jsonPosition struct {
+	Start     int `json:"start"`
+	End       int `json:"end"`
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+type jsonNode struct {
+	Type       int          `json:"type"`
+	Name       string       `json:"name,omitempty"`
+	Value      string       `json:"value,omitempty"`
+	ValueBytes []byte       `json:"valueBytes,omitempty"`
+	Position   jsonPosition `json:"position"`
+	ActualId   string       `json:"actualId,omitempty"`
+	Children   []*jsonNode  `json:"children,omitempty"`
+}
+
+func toJSONNode(node *ParseTree, types NodeTypes) *jsonNode {
+	if node == nil {
+		return nil
+	}
+	out := &jsonNode{
+		Type:     node.Type,
+		ActualId: node.ActualId,
+		Position: jsonPosition{
+			Start:     node.Position.StartPosition,
+			End:       node.Position.EndPosition,
+			StartLine: node.Position.StartLine,
+			EndLine:   node.Position.EndLine,
+		},
+	}
+	out.Value, out.ValueBytes = jsonValue(node.Value)
+	if types != nil {
+		out.Name = types.Name(node.Type)
+	}
+	for _, child := range node.Children {
+		out.Children = append(out.Children, toJSONNode(child, types))
+	}
+	return out
+}
+
+/*
+	Writes indented JSON, including positions and values
+*/
+func WriteJSON(w io.Writer, tree *ParseTree, types NodeTypes) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(toJSONNode(tree, types))
+}
+
+/*
+	Writes XML, one element per node named after its type
+*/
+func WriteXML(w io.Writer, tree *ParseTree, types NodeTypes) error {
+	out := bufio.NewWriter(w)
+	out.WriteString(xml.Header)
+	writeXML(out, 0, tree, types)
+	return out.Flush()
+}
+
+func writeXML(out *bufio.Writer, level int, node *ParseTree, types NodeTypes) {
+	if node == nil {
+		return
+	}
+	indent := strings.Repeat("  ", level)
+	name := types.Name(node.Type)
+	element := name
+	if !isXMLName(name) {
+		element = "node"
+	}
+	fmt.Fprintf(out, `%s<%s type="%d" start="%d" end="%d" startLine="%d" endLine="%d"`,
+		indent, element, node.Type,
+		node.Position.StartPosition, node.Position.EndPosition,
+		node.Position.StartLine, node.Position.EndLine)
+	if element != name {
+		out.WriteString(` name="`)
+		xml.EscapeText(out, []byte(name))
+		out.WriteString(`"`)
+	}
+	if len(node.Value) == 0 && len(node.Children) == 0 {
+		out.WriteString("/>\n")
+		return
+	}
+	out.WriteString(">")
+	xml.EscapeText(out, node.Value)
+	if len(node.Children) > 0 {
+		out.WriteString("\n")
+		for _, child := range node.Children {
+			writeXML(out, level+1, child, types)
+		}
+		out.WriteString(indent)
+	}
+	fmt.Fprintf(out, "</%s>\n", element)
+}
+
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, c := range name {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || !(c == '-' || c == '.' || (c >= '0' && c <= '9'))) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+	Writes a Graphviz DOT digraph
+*/
+func WriteDOT(w io.Writer, tree *ParseTree, types NodeTypes) error {
+	out := bufio.NewWriter(w)
+	out.WriteString("digraph ParseTree {\n")
+	out.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	if tree != nil {
+		id := 0
+		writeDOT(out, &id, tree, types)
+	}
+	out.WriteString("}\n")
+	return out.Flush()
+}
+
+func writeDOT(out *bufio.Writer, id *int, node *ParseTree, types NodeTypes) int {
+	current := *id
+	*id += 1
+	label := types.Name(node.Type)
+	if len(node.Value) > 0 {
+		label += "\n" + strconv.Quote(string(node.Value))
+	}
+	fmt.Fprintf(out, "  n%d [label=%s];\n", current, strconv.Quote(label))
+	for _, child := range node.Children {
+		if child == nil {
+			continue
+		}
+		childId := writeDOT(out, id, child, types)
+		fmt.Fprintf(out, "  n%d -> n%d;\n", current, childId)
+	}
+	return current
+}
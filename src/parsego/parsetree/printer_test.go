@@ -0,0 +1,145 @@
+package pt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+/*
+	A tree with a value to escape, a nil child and types with no name,
+	or names that are not XML names
+*/
+func printedTree() (*ParseTree, NodeTypes) {
+	tree := &ParseTree{Type: 1, Position: InputPosition{0, 8, 1, 2}, Children: []*ParseTree{
+		{Type: 2, Value: []byte("a\"<&\nb"), Position: InputPosition{0, 6, 1, 2}},
+		nil,
+		{Type: 3, Position: InputPosition{6, 6, 2, 2}},
+		{Type: 4, Value: []byte("x"), Position: InputPosition{6, 7, 2, 2}},
+		{Type: 5, Value: []byte("y"), Position: InputPosition{7, 8, 2, 2}, ActualId: "id"},
+	}}
+	return tree, NodeTypes{1: "Program", 2: "VALUE", 4: "xml-name", 5: "two words"}
+}
+
+func printed(t *testing.T, write TreeWriter, tree *ParseTree, types NodeTypes) string {
+	var out strings.Builder
+	if err := write(&out, tree, types); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestWriteText(t *testing.T) {
+	tree, types := printedTree()
+	expected := `Program []
+|  VALUE [a"<&
+b]
+|  3 []
+|  xml-name [x]
+|  two words [y]
+`
+	if actual := printed(t, WriteText, tree, types); actual != expected {
+		t.Errorf("expected\n%sgot\n%s", expected, actual)
+	}
+	expected = `1 []
+|  2 [a"<&
+b]
+|  3 []
+|  4 [x]
+|  5 [y]
+`
+	if actual := printed(t, WriteText, tree, nil); actual != expected {
+		t.Errorf("expected without types\n%sgot\n%s", expected, actual)
+	}
+	if actual := printed(t, WriteText, nil, types); actual != "" {
+		t.Errorf("expected nothing for no tree, got %q", actual)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	tree, types := printedTree()
+	var decoded jsonNode
+	if err := json.Unmarshal([]byte(printed(t, WriteJSON, tree, types)), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	children := decoded.Children
+	if decoded.Name != "Program" || len(children) != 5 || children[1] != nil {
+		t.Fatalf("expected Program and 5 children, the second null, got %+v", decoded)
+	}
+	if children[0].Value != "a\"<&\nb" || children[0].Position != (jsonPosition{0, 6, 1, 2}) {
+		t.Errorf("expected the value and position of VALUE, got %+v", children[0])
+	}
+	if children[2].Name != "3" || children[4].Name != "two words" || children[4].ActualId != "id" {
+		t.Errorf("expected the names 3 and two words, and the id, got %+v and %+v", children[2], children[4])
+	}
+
+	actual := printed(t, WriteJSON, tree, nil)
+	if strings.Contains(actual, `"name"`) {
+		t.Errorf("expected no names without types, got\n%s", actual)
+	}
+	if !strings.Contains(actual, `"value": "a\"<&\nb"`) {
+		t.Errorf("expected the value unescaped for HTML, got\n%s", actual)
+	}
+	if actual := printed(t, WriteJSON, nil, types); actual != "null\n" {
+		t.Errorf("expected null for no tree, got %q", actual)
+	}
+}
+
+func TestWriteXML(t *testing.T) {
+	tree, types := printedTree()
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<Program type="1" start="0" end="8" startLine="1" endLine="2">
+  <VALUE type="2" start="0" end="6" startLine="1" endLine="2">a&#34;&lt;&amp;&#xA;b</VALUE>
+  <node type="3" start="6" end="6" startLine="2" endLine="2" name="3"/>
+  <node type="4" start="6" end="7" startLine="2" endLine="2" name="xml-name">x</node>
+  <node type="5" start="7" end="8" startLine="2" endLine="2" name="two words">y</node>
+</Program>
+`
+	if actual := printed(t, WriteXML, tree, types); actual != expected {
+		t.Errorf("expected\n%sgot\n%s", expected, actual)
+	}
+	expected = `<?xml version="1.0" encoding="UTF-8"?>
+<node type="1" start="0" end="8" startLine="1" endLine="2" name="1">
+  <node type="2" start="0" end="6" startLine="1" endLine="2" name="2">a&#34;&lt;&amp;&#xA;b</node>
+  <node type="3" start="6" end="6" startLine="2" endLine="2" name="3"/>
+  <node type="4" start="6" end="7" startLine="2" endLine="2" name="4">x</node>
+  <node type="5" start="7" end="8" startLine="2" endLine="2" name="5">y</node>
+</node>
+`
+	if actual := printed(t, WriteXML, tree, nil); actual != expected {
+		t.Errorf("expected without types\n%sgot\n%s", expected, actual)
+	}
+
+	quoted := &ParseTree{Type: 6}
+	expected = `<node type="6" start="0" end="0" startLine="0" endLine="0" name="&#34;a&lt;b&#34;"/>`
+	if actual := printed(t, WriteXML, quoted, NodeTypes{6: `"a<b"`}); !strings.Contains(actual, expected) {
+		t.Errorf("expected the name attribute escaped, got\n%s", actual)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	tree, types := printedTree()
+	expected := `digraph ParseTree {
+  node [shape=box, fontname="monospace"];
+  n0 [label="Program"];
+  n1 [label="VALUE\n\"a\\\"<&\\nb\""];
+  n0 -> n1;
+  n2 [label="3"];
+  n0 -> n2;
+  n3 [label="xml-name\n\"x\""];
+  n0 -> n3;
+  n4 [label="two words\n\"y\""];
+  n0 -> n4;
+}
+`
+	if actual := printed(t, WriteDOT, tree, types); actual != expected {
+		t.Errorf("expected\n%sgot\n%s", expected, actual)
+	}
+	if actual := printed(t, WriteDOT, tree, nil); !strings.Contains(actual, `n4 [label="5\n\"y\""];`) {
+		t.Errorf("expected numbers for names without types, got\n%s", actual)
+	}
+	expected = "digraph ParseTree {\n  node [shape=box, fontname=\"monospace\"];\n}\n"
+	if actual := printed(t, WriteDOT, nil, types); actual != expected {
+		t.Errorf("expected an empty graph for no tree, got\n%s", actual)
+	}
+}
@@ -0,0 +1,468 @@
+package pt
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+	A compiled tree query, using a CSS-like selector syntax:
+
+	FOR BLOCK FUNCTION_CALL        descendants
+	FOR > BLOCK                    direct children
+	*                              any node
+	IDENTIFIER[value="i"]          value equals (also ^= $= *=, ~= for regexps)
+	FUNCTION_DEFINITION[id="f"]    ActualId equals
+	BLOCK:first-child              also :last-child, :nth-child(n), :empty
+	IFTHEN:has(BREAK)              nodes with a matching descendant
+	EXPRESSION:not(:empty)         nodes not matching a selector
+	IFTHEN, IFTHENELSE             union
+
+	Node types are written by name, as found in the NodeTypes given to
+	Compile, or by number.
+*/
+type Query struct {
+	source    string
+	selectors []selector
+}
+
+type selector []step
+
+type step struct {
+	combinator byte
+	match      compound
+}
+
+type compound struct {
+	anyType  bool
+	nodeType int
+	filters  []filter
+}
+
+type filter func(node *ParseTree, ancestors []*ParseTree) bool
+
+/*
+	Compiles a query, resolving type names with types
+*/
+func Compile(query string, types NodeTypes) (*Query, error) {
+	names := make(map[string]int)
+	for nodeType, name := range types {
+		names[name] = nodeType
+	}
+	parser := &queryParser{source: query, names: names}
+	selectors, err := parser.parseSelectors()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(query) {
+		return nil, parser.errorf("unexpected %q", query[parser.position])
+	}
+	return &Query{source: query, selectors: selectors}, nil
+}
+
+/*
+	Compiles a query, panicking on errors
+*/
+func MustCompile(query string, types NodeTypes) *Query {
+	compiled, err := Compile(query, types)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+func (self *Query) String() string {
+	return self.source
+}
+
+/*
+	Reports whether node matches, given its ancestors from the root down
+*/
+func (self *Query) Match(node *ParseTree, ancestors []*ParseTree) bool {
+	return self.match(node, ancestors, 0)
+}
+
+/*
+	Combinators only look at the ancestors from scope on
+*/
+func (self *Query) match(node *ParseTree, ancestors []*ParseTree, scope int) bool {
+	for _, sel := range self.selectors {
+		if sel.match(len(sel)-1, node, ancestors, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	Returns all nodes of the tree matching query, in pre-order
+*/
+func (self *ParseTree) Query(query *Query) []*ParseTree {
+	found := []*ParseTree{}
+	queryTree(self, nil, 0, query, &found)
+	return found
+}
+
+func queryTree(node *ParseTree, ancestors []*ParseTree, scope int, query *Query, found *[]*ParseTree) {
+	if node == nil {
+		return
+	}
+	if query.match(node, ancestors, scope) {
+		*found = append(*found, node)
+	}
+	ancestors = append(ancestors, node)
+	for _, child := range node.Children {
+		queryTree(child, ancestors, scope, query, found)
+	}
+}
+
+func (self selector) match(i int, node *ParseTree, ancestors []*ParseTree, scope int) bool {
+	if !self[i].match.matches(node, ancestors) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	last := len(ancestors) - 1
+	switch self[i].combinator {
+	case '>':
+		return last >= scope && self.match(i-1, ancestors[last], ancestors[:last], scope)
+	default:
+		for j := last; j >= scope; j -= 1 {
+			if self.match(i-1, ancestors[j], ancestors[:j], scope) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (self *compound) matches(node *ParseTree, ancestors []*ParseTree) bool {
+	if !self.anyType && node.Type != self.nodeType {
+		return false
+	}
+	for _, f := range self.filters {
+		if !f(node, ancestors) {
+			return false
+		}
+	}
+	return true
+}
+
+func siblingIndex(node *ParseTree, ancestors []*ParseTree) (int, int) {
+	if len(ancestors) == 0 {
+		return 0, 1
+	}
+	siblings := ancestors[len(ancestors)-1].Children
+	for i, sibling := range siblings {
+		if sibling == node {
+			return i, len(siblings)
+		}
+	}
+	return -1, len(siblings)
+}
+
+/*
+	Selector parsing
+*/
+
+type queryParser struct {
+	source   string
+	position int
+	names    map[string]int
+}
+
+func (self *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("pt: query %q, offset %d: %s", self.source, self.position, fmt.Sprintf(format, args...))
+}
+
+func (self *queryParser) peek() byte {
+	if self.position >= len(self.source) {
+		return 0
+	}
+	return self.source[self.position]
+}
+
+func (self *queryParser) skipSpaces() bool {
+	start := self.position
+	for self.position < len(self.source) && strings.IndexByte(" \t\r\n", self.source[self.position]) >= 0 {
+		self.position += 1
+	}
+	return self.position > start
+}
+
+func (self *queryParser) parseSelectors() ([]selector, error) {
+	selectors := []selector{}
+	for {
+		self.skipSpaces()
+		sel, err := self.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		self.skipSpaces()
+		if self.peek() != ',' {
+			return selectors, nil
+		}
+		self.position += 1
+	}
+}
+
+func (self *queryParser) parseSelector() (selector, error) {
+	sel := selector{}
+	combinator := byte(' ')
+	for {
+		match, err := self.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, step{combinator: combinator, match: match})
+
+		spaced := self.skipSpaces()
+		switch self.peek() {
+		case '>':
+			self.position += 1
+			self.skipSpaces()
+			combinator = '>'
+		case 0, ',', ')':
+			return sel, nil
+		default:
+			if !spaced {
+				return nil, self.errorf("unexpected %q", self.peek())
+			}
+			combinator = ' '
+		}
+	}
+}
+
+func (self *queryParser) parseCompound() (compound, error) {
+	match := compound{}
+	switch c := self.peek(); {
+	case c == '*':
+		self.position += 1
+		match.anyType = true
+	case isNameChar(c):
+		name := self.parseName()
+		if nodeType, ok := self.names[name]; ok {
+			match.nodeType = nodeType
+		} else if nodeType, err := strconv.Atoi(name); err == nil {
+			match.nodeType = nodeType
+		} else {
+			return match, self.errorf("unknown node type %q", name)
+		}
+	case c == '[' || c == ':':
+		match.anyType = true
+	default:
+		return match, self.errorf("expected node type")
+	}
+
+	for {
+		switch self.peek() {
+		case '[':
+			f, err := self.parseAttribute()
+			if err != nil {
+				return match, err
+			}
+			match.filters = append(match.filters, f)
+		case ':':
+			f, err := self.parsePseudo()
+			if err != nil {
+				return match, err
+			}
+			match.filters = append(match.filters, f)
+		default:
+			return match, nil
+		}
+	}
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (self *queryParser) parseName() string {
+	start := self.position
+	for self.position < len(self.source) && isNameChar(self.source[self.position]) {
+		self.position += 1
+	}
+	return self.source[start:self.position]
+}
+
+func (self *queryParser) parseAttribute() (filter, error) {
+	self.position += 1
+	self.skipSpaces()
+	attribute := self.parseName()
+	var get func(node *ParseTree) []byte
+	switch attribute {
+	case "value":
+		get = func(node *ParseTree) []byte { return node.Value }
+	case "id":
+		get = func(node *ParseTree) []byte { return []byte(node.ActualId) }
+	default:
+		return nil, self.errorf("unknown attribute %q", attribute)
+	}
+	self.skipSpaces()
+
+	operator := ""
+	for self.peek() != 0 && strings.IndexByte("=^$*~", self.peek()) >= 0 {
+		operator += string(self.peek())
+		self.position += 1
+	}
+	self.skipSpaces()
+	if operator == "" && self.peek() == ']' {
+		self.position += 1
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			return len(get(node)) > 0
+		}, nil
+	}
+	operand, err := self.parseString()
+	if err != nil {
+		return nil, err
+	}
+	self.skipSpaces()
+	if self.peek() != ']' {
+		return nil, self.errorf("expected ']'")
+	}
+	self.position += 1
+
+	expected := []byte(operand)
+	switch operator {
+	case "=":
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			return bytes.Equal(get(node), expected)
+		}, nil
+	case "^=":
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			return bytes.HasPrefix(get(node), expected)
+		}, nil
+	case "$=":
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			return bytes.HasSuffix(get(node), expected)
+		}, nil
+	case "*=":
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			return bytes.Contains(get(node), expected)
+		}, nil
+	case "~=":
+		pattern, err := regexp.Compile(operand)
+		if err != nil {
+			return nil, self.errorf("%s", err)
+		}
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			return pattern.Match(get(node))
+		}, nil
+	}
+	return nil, self.errorf("unknown operator %q", operator)
+}
+
+func (self *queryParser) parseString() (string, error) {
+	quote := self.peek()
+	if quote != '"' && quote != '\'' {
+		return "", self.errorf("expected quoted string")
+	}
+	start := self.position
+	self.position += 1
+	for self.position < len(self.source) && self.source[self.position] != quote {
+		if self.source[self.position] == '\\' {
+			self.position += 1
+		}
+		self.position += 1
+	}
+	if self.position >= len(self.source) {
+		return "", self.errorf("unterminated string")
+	}
+	self.position += 1
+	literal := self.source[start:self.position]
+	if quote == '\'' {
+		literal = `"` + strings.ReplaceAll(literal[1:len(literal)-1], `"`, `\"`) + `"`
+	}
+	value, err := strconv.Unquote(literal)
+	if err != nil {
+		return "", self.errorf("invalid string %s", literal)
+	}
+	return value, nil
+}
+
+func (self *queryParser) parsePseudo() (filter, error) {
+	self.position += 1
+	name := self.parseName()
+	switch name {
+	case "first-child":
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			index, _ := siblingIndex(node, ancestors)
+			return index == 0
+		}, nil
+	case "last-child":
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			index, count := siblingIndex(node, ancestors)
+			return index == count-1
+		}, nil
+	case "empty":
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			return len(node.Children) == 0
+		}, nil
+	case "nth-child":
+		argument, err := self.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(argument))
+		if err != nil {
+			return nil, self.errorf("invalid :nth-child argument %q", argument)
+		}
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			index, _ := siblingIndex(node, ancestors)
+			return index == n-1
+		}, nil
+	case "has", "not":
+		if self.peek() != '(' {
+			return nil, self.errorf("expected '('")
+		}
+		self.position += 1
+		selectors, err := self.parseSelectors()
+		if err != nil {
+			return nil, err
+		}
+		self.skipSpaces()
+		if self.peek() != ')' {
+			return nil, self.errorf("expected ')'")
+		}
+		self.position += 1
+		inner := &Query{selectors: selectors}
+		if name == "not" {
+			return func(node *ParseTree, ancestors []*ParseTree) bool {
+				return !inner.Match(node, ancestors)
+			}, nil
+		}
+		// descendants see all their ancestors, for :first-child and the
+		// like, but their selectors stay below node
+		return func(node *ParseTree, ancestors []*ParseTree) bool {
+			found := []*ParseTree{}
+			ancestors = append(ancestors[:len(ancestors):len(ancestors)], node)
+			for _, child := range node.Children {
+				queryTree(child, ancestors, len(ancestors), inner, &found)
+				if len(found) > 0 {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	return nil, self.errorf("unknown pseudo-class %q", name)
+}
+
+func (self *queryParser) parseArgument() (string, error) {
+	if self.peek() != '(' {
+		return "", self.errorf("expected '('")
+	}
+	end := strings.IndexByte(self.source[self.position:], ')')
+	if end < 0 {
+		return "", self.errorf("expected ')'")
+	}
+	argument := self.source[self.position+1 : self.position+end]
+	self.position += end + 1
+	return argument, nil
+}
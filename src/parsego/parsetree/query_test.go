@@ -0,0 +1,110 @@
+package pt
+
+import (
+	"strings"
+	"testing"
+)
+
+var queryTypes = NodeTypes{1: "PROGRAM", 2: "FOR", 3: "IDENTIFIER", 4: "BLOCK", 5: "CALL", 6: "BREAK"}
+
+/*
+	A node named id, for tests to tell nodes apart
+*/
+func node(id string, nodeType int, value string, children ...*ParseTree) *ParseTree {
+	tree := &ParseTree{Type: nodeType, ActualId: id, Children: children}
+	if value != "" {
+		tree.Value = []byte(value)
+	}
+	return tree
+}
+
+func sampleProgram() *ParseTree {
+	return node("program", 1, "",
+		node("for", 2, "",
+			node("i", 3, "i"),
+			node("body", 4, "",
+				node("call", 5, "",
+					node("print", 3, "print")),
+				node("break", 6, ""))),
+		node("empty", 4, ""),
+		node("item", 3, "item"))
+}
+
+func ids(nodes []*ParseTree) string {
+	names := []string{}
+	for _, node := range nodes {
+		names = append(names, node.ActualId)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"IDENTIFIER", "i print item"},
+		{"6", "break"},
+		{"*", "program for i body call print break empty item"},
+		{"FOR IDENTIFIER", "i print"},
+		{"FOR > IDENTIFIER", "i"},
+		{"PROGRAM > *", "for empty item"},
+		{"PROGRAM BLOCK > BREAK", "break"},
+		{"BLOCK, BREAK", "body break empty"},
+		{`IDENTIFIER[value="i"]`, "i"},
+		{`IDENTIFIER[value^="pr"]`, "print"},
+		{`IDENTIFIER[value$="em"]`, "item"},
+		{`IDENTIFIER[value*="rin"]`, "print"},
+		{`IDENTIFIER[value~="^i.*m$"]`, "item"},
+		{`[value]`, "i print item"},
+		{`[id='call']`, "call"},
+		{":first-child", "program for i call print"},
+		{"BLOCK:last-child", "body"},
+		{":nth-child(2)", "body break empty"},
+		{"BLOCK:empty", "empty"},
+		{"BLOCK:not(:empty)", "body"},
+		{"FOR:has(BREAK)", "for"},
+		{"*:has(CALL > IDENTIFIER)", "program for body"},
+		{"*:has(BLOCK IDENTIFIER)", "program for"},
+		{"CALL:has(BLOCK IDENTIFIER)", ""},
+		{"FOR:has(IDENTIFIER:first-child)", "for"},
+		{"BLOCK:has(BREAK:first-child)", ""},
+		{"BLOCK:has(CALL:last-child)", ""},
+		{"PROGRAM:has(BLOCK:nth-child(2))", "program"},
+		{"FOR:has(BLOCK:nth-child(1))", ""},
+		{"BREAK:not(:first-child)", "break"},
+	}
+	tree := sampleProgram()
+	for _, test := range tests {
+		query, err := Compile(test.query, queryTypes)
+		if err != nil {
+			t.Errorf("%s: %s", test.query, err)
+			continue
+		}
+		if found := ids(tree.Query(query)); found != test.expected {
+			t.Errorf("%s: got %q, want %q", test.query, found, test.expected)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"UNKNOWN",
+		"FOR >",
+		"FOR )",
+		"[name]",
+		`[value="i"`,
+		`[value="i]`,
+		`[value%="i"]`,
+		`[value~="("]`,
+		":nth-child(x)",
+		":nth-child(1",
+		":has(FOR",
+		":bogus",
+	} {
+		if _, err := Compile(query, queryTypes); err == nil {
+			t.Errorf("%q compiled", query)
+		}
+	}
+}
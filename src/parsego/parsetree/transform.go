@@ -0,0 +1,194 @@
+package pt
+
+import (
+	"math"
+)
+
+/*
+	Rewrites a single node: returns the node to put in its place (the node
+	itself to keep it unchanged) and false to delete it.
+	Return Splice(...) to insert several nodes in place of one,
+	or Wrap(...) to nest the node inside a new one.
+*/
+type Rewriter func(node *ParseTree) (*ParseTree, bool)
+
+/*
+	A pattern-based rewrite rule: nodes matching Match are passed
+	to Rewrite, together with their ancestors from the root down
+*/
+type Rule struct {
+	Match   *Query
+	Rewrite func(node *ParseTree, ancestors []*ParseTree) (*ParseTree, bool)
+}
+
+const spliceType = math.MinInt32
+
+/*
+	Transforms the tree in place, bottom up: children are rewritten before
+	their parent. Returns the new root, nil if the root was deleted;
+	a root spliced into several nodes is returned as their untyped parent.
+*/
+func Transform(tree *ParseTree, rewrite Rewriter) *ParseTree {
+	return transformRoot(tree, false, func(node *ParseTree, ancestors []*ParseTree) (*ParseTree, bool) {
+		return rewrite(node)
+	})
+}
+
+/*
+	Transforms the tree in place, top down: a node is rewritten before
+	its children, and the children of its replacement are visited,
+	those of the node itself when the replacement wraps it, however deep:
+	a node is rewritten once.
+	Returns the new root, nil if the root was deleted.
+*/
+func TransformPreOrder(tree *ParseTree, rewrite Rewriter) *ParseTree {
+	return transformRoot(tree, true, func(node *ParseTree, ancestors []*ParseTree) (*ParseTree, bool) {
+		return rewrite(node)
+	})
+}
+
+/*
+	Applies rules bottom up, in place: each node is rewritten by the first
+	rule it matches. Returns the new root, nil if the root was deleted.
+*/
+func Rewrite(tree *ParseTree, rules ...Rule) *ParseTree {
+	return transformRoot(tree, false, func(node *ParseTree, ancestors []*ParseTree) (*ParseTree, bool) {
+		for _, rule := range rules {
+			if rule.Match.Match(node, ancestors) {
+				return rule.Rewrite(node, ancestors)
+			}
+		}
+		return node, true
+	})
+}
+
+type rewriteFunc func(node *ParseTree, ancestors []*ParseTree) (*ParseTree, bool)
+
+func transformRoot(tree *ParseTree, preOrder bool, rewrite rewriteFunc) *ParseTree {
+	out := transform(tree, nil, preOrder, nil, rewrite)
+	switch len(out) {
+	case 0:
+		return nil
+	case 1:
+		return out[0]
+	}
+	root := new(ParseTree)
+	root.Children = out
+	return root
+}
+
+/*
+	Rewrites node and its descendants but the nodes of rewritten, already
+	rewritten above and showing up in their own replacements, at any depth,
+	whose children only are visited
+*/
+func transform(node *ParseTree, ancestors []*ParseTree, preOrder bool, rewritten []*ParseTree, rewrite rewriteFunc) []*ParseTree {
+	if node == nil {
+		return nil
+	}
+	for _, done := range rewritten {
+		if node == done {
+			transformChildren(node, ancestors, preOrder, rewritten, rewrite)
+			return []*ParseTree{node}
+		}
+	}
+	if preOrder {
+		replaced, ok := rewrite(node, ancestors)
+		if !ok || replaced == nil {
+			return nil
+		}
+		rewritten = append(rewritten[:len(rewritten):len(rewritten)], node)
+		out := []*ParseTree{}
+		for _, n := range flatten(replaced) {
+			transformChildren(n, ancestors, preOrder, rewritten, rewrite)
+			out = append(out, n)
+		}
+		return out
+	}
+	transformChildren(node, ancestors, preOrder, nil, rewrite)
+	replaced, ok := rewrite(node, ancestors)
+	if !ok || replaced == nil {
+		return nil
+	}
+	return flatten(replaced)
+}
+
+func transformChildren(node *ParseTree, ancestors []*ParseTree, preOrder bool, rewritten []*ParseTree, rewrite rewriteFunc) {
+	if len(node.Children) == 0 {
+		return
+	}
+	ancestors = append(ancestors, node)
+	children := []*ParseTree{}
+	for _, child := range node.Children {
+		children = append(children, transform(child, ancestors, preOrder, rewritten, rewrite)...)
+	}
+	node.Children = children
+}
+
+func flatten(node *ParseTree) []*ParseTree {
+	if node.Type != spliceType {
+		return []*ParseTree{node}
+	}
+	out := []*ParseTree{}
+	for _, child := range node.Children {
+		if child != nil {
+			out = append(out, flatten(child)...)
+		}
+	}
+	return out
+}
+
+/*
+	Returns a placeholder that a Rewriter can use to replace
+	a node with all of nodes, in order
+*/
+func Splice(nodes ...*ParseTree) *ParseTree {
+	node := new(ParseTree)
+	node.Type = spliceType
+	node.Children = nodes
+	return node
+}
+
+/*
+	Returns a new node of type nodeType having node as its only child,
+	spanning the same input
+*/
+func Wrap(nodeType int, node *ParseTree) *ParseTree {
+	wrapper := new(ParseTree)
+	wrapper.Type = nodeType
+	wrapper.Position = node.Position
+	wrapper.Children = []*ParseTree{node}
+	return wrapper
+}
+
+/*
+	Inserts nodes among the children of parent, before index
+*/
+func InsertChildren(parent *ParseTree, index int, nodes ...*ParseTree) {
+	children := make([]*ParseTree, 0, len(parent.Children)+len(nodes))
+	children = append(children, parent.Children[:index]...)
+	children = append(children, nodes...)
+	children = append(children, parent.Children[index:]...)
+	parent.Children = children
+}
+
+/*
+	Returns a deep copy of the tree
+*/
+func Clone(tree *ParseTree) *ParseTree {
+	if tree == nil {
+		return nil
+	}
+	clone := new(ParseTree)
+	*clone = *tree
+	if tree.Value != nil {
+		clone.Value = append([]byte{}, tree.Value...)
+	}
+	if tree.Children != nil {
+		clone.Children = make([]*ParseTree, len(tree.Children))
+		for i, child := range tree.Children {
+			clone.Children[i] = Clone(child)
+		}
+	}
+	return clone
+}
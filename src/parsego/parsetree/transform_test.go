@@ -0,0 +1,160 @@
+package pt
+
+import (
+	"strings"
+	"testing"
+)
+
+/*
+	The ids of the tree, as nested lists: program(for(i body) empty)
+*/
+func shape(tree *ParseTree) string {
+	if tree == nil {
+		return ""
+	}
+	if len(tree.Children) == 0 {
+		return tree.ActualId
+	}
+	children := []string{}
+	for _, child := range tree.Children {
+		children = append(children, shape(child))
+	}
+	return tree.ActualId + "(" + strings.Join(children, " ") + ")"
+}
+
+func TestTransform(t *testing.T) {
+	order := []string{}
+	tree := Transform(sampleProgram(), func(node *ParseTree) (*ParseTree, bool) {
+		order = append(order, node.ActualId)
+		switch node.Type {
+		case 6:
+			return nil, false
+		case 5:
+			return Splice(node.Children...), true
+		case 3:
+			wrapper := Wrap(7, node)
+			wrapper.ActualId = "wrapped"
+			return wrapper, true
+		}
+		return node, true
+	})
+	expected := "program(for(wrapped(i) body(wrapped(print))) empty wrapped(item))"
+	if actual := shape(tree); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	visited := "i print call break body for empty item program"
+	if actual := strings.Join(order, " "); actual != visited {
+		t.Errorf("expected to visit %s, visited %s", visited, actual)
+	}
+}
+
+func TestTransformPreOrder(t *testing.T) {
+	order := []string{}
+	tree := TransformPreOrder(sampleProgram(), func(node *ParseTree) (*ParseTree, bool) {
+		order = append(order, node.ActualId)
+		switch node.Type {
+		case 2, 3:
+			wrapper := Wrap(7, node)
+			wrapper.ActualId = "wrapped-" + node.ActualId
+			return wrapper, true
+		case 5:
+			return Splice(node.Children...), true
+		}
+		return node, true
+	})
+	expected := "program(wrapped-for(for(wrapped-i(i) body(print break))) empty wrapped-item(item))"
+	if actual := shape(tree); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	visited := "program for i body call break empty item"
+	if actual := strings.Join(order, " "); actual != visited {
+		t.Errorf("expected to visit %s, visited %s", visited, actual)
+	}
+}
+
+/*
+	A node wrapped twice is rewritten once, the inner wrapper being
+	visited as a child of the outer one
+*/
+func TestTransformPreOrderNestedWrap(t *testing.T) {
+	order := []string{}
+	tree := TransformPreOrder(sampleProgram(), func(node *ParseTree) (*ParseTree, bool) {
+		order = append(order, node.ActualId)
+		if node.Type == 3 {
+			inner := Wrap(8, node)
+			inner.ActualId = "inner-" + node.ActualId
+			outer := Wrap(7, inner)
+			outer.ActualId = "outer-" + node.ActualId
+			return outer, true
+		}
+		return node, true
+	})
+	expected := "program(for(outer-i(inner-i(i)) body(call(outer-print(inner-print(print))) break)) empty outer-item(inner-item(item)))"
+	if actual := shape(tree); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	visited := "program for i inner-i body call print inner-print break empty item inner-item"
+	if actual := strings.Join(order, " "); actual != visited {
+		t.Errorf("expected to visit %s, visited %s", visited, actual)
+	}
+}
+
+func TestTransformRoot(t *testing.T) {
+	deleted := Transform(sampleProgram(), func(node *ParseTree) (*ParseTree, bool) {
+		return node, node.Type != 1
+	})
+	if deleted != nil {
+		t.Errorf("expected the root to be deleted, got %s", shape(deleted))
+	}
+	spliced := Transform(sampleProgram(), func(node *ParseTree) (*ParseTree, bool) {
+		if node.Type == 1 {
+			return Splice(node.Children...), true
+		}
+		return node, true
+	})
+	expected := "(for(i body(call(print) break)) empty item)"
+	if actual := shape(spliced); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	query, err := Compile("BLOCK > *", queryTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parents := []string{}
+	tree := Rewrite(sampleProgram(), Rule{
+		Match: query,
+		Rewrite: func(node *ParseTree, ancestors []*ParseTree) (*ParseTree, bool) {
+			parents = append(parents, ids(ancestors))
+			return nil, false
+		},
+	})
+	expected := "program(for(i body) empty item)"
+	if actual := shape(tree); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	ancestors := "program for body,program for body"
+	if actual := strings.Join(parents, ","); actual != ancestors {
+		t.Errorf("expected ancestors %s, got %s", ancestors, actual)
+	}
+}
+
+func TestInsertChildrenAndClone(t *testing.T) {
+	tree := sampleProgram()
+	clone := Clone(tree)
+	InsertChildren(tree, 1, node("first", 6, ""), node("second", 6, ""))
+	expected := "program(for(i body(call(print) break)) first second empty item)"
+	if actual := shape(tree); actual != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+	original := "program(for(i body(call(print) break)) empty item)"
+	if actual := shape(clone); actual != original {
+		t.Errorf("expected the clone to stay %s, got %s", original, actual)
+	}
+	clone.Children[2].Value[0] = 'X'
+	if string(tree.Children[4].Value) != "item" {
+		t.Errorf("expected the clone to copy values, got %q", tree.Children[4].Value)
+	}
+}
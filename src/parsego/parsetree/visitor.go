@@ -0,0 +1,96 @@
+package pt
+
+/*
+	Tells Visit how to go on after a node has been entered or left
+*/
+type WalkAction int
+
+const (
+	Continue WalkAction = iota
+	SkipChildren
+	Stop
+)
+
+/*
+	Receives nodes from Visit, together with their ancestors from the root
+	down. The ancestors slice is reused: copy it to keep it.
+	Leave is called for every entered node, even if its children were
+	skipped, unless the walk has been stopped.
+*/
+type Visitor interface {
+	Enter(node *ParseTree, ancestors []*ParseTree) WalkAction
+	Leave(node *ParseTree, ancestors []*ParseTree) WalkAction
+}
+
+/*
+	Adapts a pair of functions to Visitor, either can be nil
+*/
+type VisitorFuncs struct {
+	EnterFunc func(node *ParseTree, ancestors []*ParseTree) WalkAction
+	LeaveFunc func(node *ParseTree, ancestors []*ParseTree) WalkAction
+}
+
+func (self VisitorFuncs) Enter(node *ParseTree, ancestors []*ParseTree) WalkAction {
+	if self.EnterFunc == nil {
+		return Continue
+	}
+	return self.EnterFunc(node, ancestors)
+}
+
+func (self VisitorFuncs) Leave(node *ParseTree, ancestors []*ParseTree) WalkAction {
+	if self.LeaveFunc == nil {
+		return Continue
+	}
+	return self.LeaveFunc(node, ancestors)
+}
+
+type visitFrame struct {
+	node  *ParseTree
+	child int
+}
+
+/*
+	Walks the tree depth first without recursion.
+	Returns false if the visitor stopped the walk.
+*/
+func (self *ParseTree) Visit(visitor Visitor) bool {
+	if self == nil {
+		return true
+	}
+	stack := []visitFrame{}
+	ancestors := []*ParseTree{}
+
+	enter := func(node *ParseTree) bool {
+		switch visitor.Enter(node, ancestors) {
+		case Stop:
+			return false
+		case SkipChildren:
+			return visitor.Leave(node, ancestors) != Stop
+		}
+		stack = append(stack, visitFrame{node: node})
+		ancestors = append(ancestors, node)
+		return true
+	}
+
+	if !enter(self) {
+		return false
+	}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.child < len(top.node.Children) {
+			child := top.node.Children[top.child]
+			top.child += 1
+			if child != nil && !enter(child) {
+				return false
+			}
+			continue
+		}
+		node := top.node
+		stack = stack[:len(stack)-1]
+		ancestors = ancestors[:len(ancestors)-1]
+		if visitor.Leave(node, ancestors) == Stop {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package pt
+
+import (
+	"strings"
+	"testing"
+)
+
+/*
+	Records +id on enter and -id on leave, returning the action
+	set for the node, Continue by default
+*/
+type recorder struct {
+	events []string
+	enter  map[string]WalkAction
+	leave  map[string]WalkAction
+}
+
+func (self *recorder) Enter(node *ParseTree, ancestors []*ParseTree) WalkAction {
+	self.events = append(self.events, "+"+node.ActualId)
+	return self.enter[node.ActualId]
+}
+
+func (self *recorder) Leave(node *ParseTree, ancestors []*ParseTree) WalkAction {
+	self.events = append(self.events, "-"+node.ActualId)
+	return self.leave[node.ActualId]
+}
+
+func TestVisit(t *testing.T) {
+	tests := []struct {
+		name     string
+		enter    map[string]WalkAction
+		leave    map[string]WalkAction
+		expected string
+		complete bool
+	}{
+		{"all", nil, nil,
+			"+program +for +i -i +body +call +print -print -call +break -break -body -for +empty -empty +item -item -program", true},
+		{"skip children", map[string]WalkAction{"body": SkipChildren}, nil,
+			"+program +for +i -i +body -body -for +empty -empty +item -item -program", true},
+		{"skip the root", map[string]WalkAction{"program": SkipChildren}, nil,
+			"+program -program", true},
+		{"stop on enter", map[string]WalkAction{"call": Stop}, nil,
+			"+program +for +i -i +body +call", false},
+		{"stop on leave", nil, map[string]WalkAction{"body": Stop},
+			"+program +for +i -i +body +call +print -print -call +break -break -body", false},
+		{"stop on leave of a skipped node", map[string]WalkAction{"for": SkipChildren}, map[string]WalkAction{"for": Stop},
+			"+program +for -for", false},
+		{"skip children on leave", nil, map[string]WalkAction{"i": SkipChildren},
+			"+program +for +i -i +body +call +print -print -call +break -break -body -for +empty -empty +item -item -program", true},
+	}
+	for _, test := range tests {
+		visitor := &recorder{enter: test.enter, leave: test.leave}
+		complete := sampleProgram().Visit(visitor)
+		if actual := strings.Join(visitor.events, " "); actual != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, actual)
+		}
+		if complete != test.complete {
+			t.Errorf("%s: expected Visit to return %t, got %t", test.name, test.complete, complete)
+		}
+	}
+}
+
+func TestVisitAncestors(t *testing.T) {
+	tree := sampleProgram()
+	tree.Children = append(tree.Children, nil)
+	paths := []string{}
+	tree.Visit(VisitorFuncs{
+		EnterFunc: func(node *ParseTree, ancestors []*ParseTree) WalkAction {
+			paths = append(paths, ids(append(append([]*ParseTree{}, ancestors...), node)))
+			return Continue
+		},
+		LeaveFunc: func(node *ParseTree, ancestors []*ParseTree) WalkAction {
+			if node.ActualId == "print" && ids(ancestors) != "program for body call" {
+				t.Errorf("expected to leave print below program for body call, got %s", ids(ancestors))
+			}
+			return Continue
+		},
+	})
+	expected := []string{
+		"program",
+		"program for",
+		"program for i",
+		"program for body",
+		"program for body call",
+		"program for body call print",
+		"program for body break",
+		"program empty",
+		"program item",
+	}
+	if actual, wanted := strings.Join(paths, ","), strings.Join(expected, ","); actual != wanted {
+		t.Errorf("expected %s, got %s", wanted, actual)
+	}
+}
+
+func TestVisitNil(t *testing.T) {
+	var tree *ParseTree
+	if !tree.Visit(VisitorFuncs{}) {
+		t.Error("expected visiting a nil tree to complete")
+	}
+}
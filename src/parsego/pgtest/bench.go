@@ -0,0 +1,78 @@
+package pgtest
+
+import (
+	"fmt"
+	"parsego/parser"
+	"testing"
+)
+
+/*
+	Benchmarks parsing the whole input with rule, which must succeed.
+	Besides time and allocations per parse, reports the time per byte
+	of input, ns/byte, and the bytes read per byte of input, probes/byte,
+	which grows with backtracking.
+	Rules reach some of their parsers on their first parse only,
+	which callers leave out by parsing once before.
+*/
+func Benchmark(b *testing.B, rule pg.Parser, input string) {
+	benchmark(b, rule, input, false)
+}
+
+/*
+	Benchmark, with the trees allocated from an arena released after
+	each parse
+*/
+func BenchmarkArena(b *testing.B, rule pg.Parser, input string) {
+	benchmark(b, rule, input, true)
+}
+
+func benchmark(b *testing.B, rule pg.Parser, input string, arena bool) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(input)))
+	probes := 0
+	for i := 0; i < b.N; i += 1 {
+		state := pg.InitParser()
+		state.SetInput(input)
+		if arena {
+			state.SetArena(pg.NewArena())
+		}
+		if _, err := pg.ParseWith(rule, state); err != nil {
+			b.Fatal(err)
+		}
+		probes = state.GetProbeCount()
+		if arena {
+			state.GetArena().Release()
+		}
+	}
+	if len(input) > 0 {
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(len(input)), "ns/byte")
+		b.ReportMetric(float64(probes)/float64(len(input)), "probes/byte")
+	}
+}
+
+/*
+	Runs Benchmark over the inputs generated for each size, in
+	sub-benchmarks named after the size, to show how parsing scales:
+	ns/byte staying flat as sizes grow means linear time.
+*/
+func Scaling(b *testing.B, rule pg.Parser, generate func(size int) string, sizes ...int) {
+	for _, size := range sizes {
+		input := generate(size)
+		b.Run(SizeName(size), func(b *testing.B) {
+			Benchmark(b, rule, input)
+		})
+	}
+}
+
+/*
+	1MB, 64KB or 100B
+*/
+func SizeName(size int) string {
+	switch {
+	case size >= 1<<20 && size%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", size>>20)
+	case size >= 1<<10 && size%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", size>>10)
+	}
+	return fmt.Sprintf("%dB", size)
+}
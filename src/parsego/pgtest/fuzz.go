@@ -0,0 +1,98 @@
+package pgtest
+
+import (
+	"encoding/json"
+	"math/rand"
+	"parsego/parser"
+	"parsego/parsetree"
+	"testing"
+)
+
+const FUZZ_SEEDS = 16
+
+/*
+	Prints trees back to source text
+*/
+type Printer func(trees []*pt.ParseTree) string
+
+/*
+	Native fuzzing over sentences generated from rule with pg.Generate,
+	the fuzzer mutating the random seed:
+	func FuzzProgram(f *testing.F) { pgtest.Fuzz(f, Program(), NODE_TYPES, 6, FormatProgram) }
+
+	Every sentence must parse, survive the JSON and binary encodings of
+	its trees unchanged and, unless print is nil, print back to source
+	text parsing to the same trees but for positions, and printing
+	the same way again.
+*/
+func Fuzz(f *testing.F, rule pg.Parser, types pt.NodeTypes, maxDepth int, print Printer) {
+	for seed := int64(0); seed < FUZZ_SEEDS; seed += 1 {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		sentence, err := pg.Generate(rule, rand.New(rand.NewSource(seed)), maxDepth)
+		if err != nil {
+			t.Skip(err)
+		}
+		out, err := pg.Parse(rule, sentence)
+		if err != nil {
+			t.Fatalf("generated sentence rejected: %s\n%q", err, sentence)
+		}
+		for _, tree := range out {
+			roundTrip(t, tree, types, sentence)
+		}
+		if print != nil {
+			Reprint(t, rule, types, print, out, sentence)
+		}
+	})
+}
+
+/*
+	Checks that the trees parsed from sentence print to source text
+	parsing to the same trees but for positions, which prints the same
+*/
+func Reprint(t testing.TB, rule pg.Parser, types pt.NodeTypes, print Printer, trees []*pt.ParseTree, sentence string) {
+	t.Helper()
+	printed := print(trees)
+	again, err := pg.Parse(rule, printed)
+	if err != nil {
+		t.Fatalf("printed source rejected: %s\n%q\nprinted from\n%q", err, printed, sentence)
+	}
+	if len(again) != len(trees) {
+		t.Fatalf("printed source parsed to %d trees instead of %d\n%q", len(again), len(trees), printed)
+	}
+	for i := range trees {
+		if diff := pt.Diff(trees[i], again[i], pt.EqualOptions{IgnorePositions: true}); len(diff) > 0 {
+			t.Fatalf("printed source parsed differently\n%s%q\nprinted from\n%q", diff.Format(types), printed, sentence)
+		}
+	}
+	if reprinted := print(again); reprinted != printed {
+		t.Fatalf("printed source printed differently\n%q\nthen\n%q", printed, reprinted)
+	}
+}
+
+func roundTrip(t *testing.T, tree *pt.ParseTree, types pt.NodeTypes, sentence string) {
+	encoded, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(pt.ParseTree)
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pt.Diff(tree, decoded, pt.EqualOptions{}); len(diff) > 0 {
+		t.Fatalf("JSON round-trip differs\n%s%q", diff.Format(types), sentence)
+	}
+
+	encoded, err = tree.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded = new(pt.ParseTree)
+	if err := decoded.UnmarshalBinary(encoded); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pt.Diff(tree, decoded, pt.EqualOptions{}); len(diff) > 0 {
+		t.Fatalf("binary round-trip differs\n%s%q", diff.Format(types), sentence)
+	}
+}
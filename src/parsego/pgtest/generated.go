@@ -0,0 +1,135 @@
+package pgtest
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"parsego/parser"
+	"parsego/parsetree"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+/*
+	Checks that the parser generated by pg.WriteGo in file is up to date
+	with rule. Run the tests with -update to regenerate it.
+*/
+func Generated(t *testing.T, file string, pkg string, rule pg.Parser, types pt.NodeTypes) {
+	var out bytes.Buffer
+	if err := pg.WriteGo(&out, pg.Describe(rule), types, pkg); err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		write(t, file, out.Bytes())
+		return
+	}
+	expected, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%s (run with -update to create it)", err)
+	}
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Fatalf("%s is out of date (run with -update to regenerate it)\n%s", file, lineDiff(expected, out.Bytes()))
+	}
+}
+
+/*
+	Checks that parse, calling a parser generated by pg.WriteGo, builds the
+	same trees and errors as rule for every input, its trees converted with
+	Convert:
+	out, err := program.Parse(input)
+	return pgtest.Convert(out), err
+*/
+func Equivalent(t *testing.T, rule pg.Parser, types pt.NodeTypes, parse func(input string) ([]*pt.ParseTree, error), inputs ...string) {
+	for _, input := range inputs {
+		expected, expectedErr := pg.Parse(rule, input)
+		actual, err := parse(input)
+		if errorText(err) != errorText(expectedErr) {
+			t.Fatalf("wrong error\n got: %s\nwant: %s\n%q", errorText(err), errorText(expectedErr), input)
+		}
+		if len(actual) != len(expected) {
+			t.Fatalf("got %d trees, want %d\n%q", len(actual), len(expected), input)
+		}
+		for i := range expected {
+			if diff := pt.Diff(expected[i], actual[i], pt.EqualOptions{}); len(diff) > 0 {
+				t.Fatalf("trees differ\n%s%q", diff.Format(types), input)
+			}
+		}
+	}
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return err.Error()
+}
+
+/*
+	The .input files of dir, as used by Run
+*/
+func Inputs(t testing.TB, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*.input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := []string{}
+	for _, file := range files {
+		input, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, string(input))
+	}
+	return inputs
+}
+
+/*
+	Sentences generated from rule with the seeds 0 to count-1,
+	skipping the seeds pg.Generate fails with
+*/
+func Sentences(rule pg.Parser, count int, maxDepth int) []string {
+	sentences := []string{}
+	for seed := int64(0); seed < int64(count); seed += 1 {
+		sentence, err := pg.Generate(rule, rand.New(rand.NewSource(seed)), maxDepth)
+		if err == nil {
+			sentences = append(sentences, sentence)
+		}
+	}
+	return sentences
+}
+
+/*
+	Converts the trees built by a generated parser, whose ParseTree has the
+	Value, Type, Children and Position fields of pt.ParseTree
+*/
+func Convert(trees interface{}) []*pt.ParseTree {
+	value := reflect.ValueOf(trees)
+	if value.Kind() != reflect.Slice || value.IsNil() {
+		return nil
+	}
+	out := make([]*pt.ParseTree, value.Len())
+	for i := range out {
+		out[i] = convertNode(value.Index(i))
+	}
+	return out
+}
+
+func convertNode(value reflect.Value) *pt.ParseTree {
+	if value.IsNil() {
+		return nil
+	}
+	value = value.Elem()
+	node := new(pt.ParseTree)
+	node.Value = value.FieldByName("Value").Bytes()
+	node.Type = int(value.FieldByName("Type").Int())
+	position := value.FieldByName("Position")
+	node.Position = pt.InputPosition{
+		StartPosition: int(position.FieldByName("StartPosition").Int()),
+		EndPosition:   int(position.FieldByName("EndPosition").Int()),
+		StartLine:     int(position.FieldByName("StartLine").Int()),
+		EndLine:       int(position.FieldByName("EndLine").Int()),
+	}
+	node.Children = Convert(value.FieldByName("Children").Interface())
+	return node
+}
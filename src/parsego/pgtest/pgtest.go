@@ -0,0 +1,123 @@
+package pgtest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"parsego/parser"
+	"parsego/parsetree"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden and error files with the current results")
+
+/*
+	Golden-file grammar tests: every NAME.input file in dir is parsed
+	with rule, and must either
+
+	- succeed, producing the S-expression trees stored in NAME.golden, or
+	- fail, with the "line:column: message" error stored in NAME.err
+
+	Run the tests with -update to rewrite both kinds of files.
+*/
+func Run(t *testing.T, dir string, rule pg.Parser, types pt.NodeTypes) {
+	inputs, err := filepath.Glob(filepath.Join(dir, "*.input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatalf("no .input files in %s", dir)
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".input")
+		base := strings.TrimSuffix(input, ".input")
+		t.Run(name, func(t *testing.T) {
+			runFile(t, base, rule, types)
+		})
+	}
+}
+
+func runFile(t *testing.T, base string, rule pg.Parser, types pt.NodeTypes) {
+	input, err := os.ReadFile(base + ".input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goldenFile, errFile := base+".golden", base+".err"
+
+	out, parseErr := pg.Parse(rule, string(input))
+	if *update {
+		if parseErr != nil {
+			write(t, errFile, []byte(parseErr.Error()+"\n"))
+			remove(t, goldenFile)
+		} else {
+			write(t, goldenFile, Format(out, types))
+			remove(t, errFile)
+		}
+		return
+	}
+
+	if expected, err := os.ReadFile(errFile); err == nil {
+		if parseErr == nil {
+			t.Fatalf("parse succeeded, expected error %s", strings.TrimSpace(string(expected)))
+		}
+		if parseErr.Error() != strings.TrimSpace(string(expected)) {
+			t.Fatalf("wrong error\n got: %s\nwant: %s", parseErr, strings.TrimSpace(string(expected)))
+		}
+		return
+	}
+
+	if parseErr != nil {
+		t.Fatalf("parse failed: %s", parseErr)
+	}
+	expected, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatalf("%s (run with -update to create it)", err)
+	}
+	if actual := Format(out, types); !bytes.Equal(actual, expected) {
+		t.Fatalf("tree differs from %s\n%s", goldenFile, lineDiff(expected, actual))
+	}
+}
+
+/*
+	The stable text format used for golden files
+*/
+func Format(trees []*pt.ParseTree, types pt.NodeTypes) []byte {
+	var out bytes.Buffer
+	for _, tree := range trees {
+		pt.WriteSExpr(&out, tree, types)
+	}
+	return out.Bytes()
+}
+
+func write(t *testing.T, file string, content []byte) {
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func remove(t *testing.T, file string) {
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+}
+
+func lineDiff(expected, actual []byte) string {
+	want := strings.Split(string(expected), "\n")
+	got := strings.Split(string(actual), "\n")
+	for i := 0; i < len(want) || i < len(got); i += 1 {
+		wantLine, gotLine := "<missing>", "<missing>"
+		if i < len(want) {
+			wantLine = want[i]
+		}
+		if i < len(got) {
+			gotLine = got[i]
+		}
+		if wantLine != gotLine {
+			return fmt.Sprintf("first difference at line %d\n got: %s\nwant: %s", i+1, gotLine, wantLine)
+		}
+	}
+	return ""
+}